@@ -0,0 +1,335 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MIB resolves between symbolic object names and raw OIDs, so that
+// requests and responses can be expressed as e.g. "IF-MIB::ifInOctets.2"
+// instead of the numeric OID 1.3.6.1.2.1.2.2.1.10.2.
+type MIB interface {
+	// Resolve returns the OID named by name, which may carry a trailing
+	// dotted index, such as "IF-MIB::ifInOctets.2".
+	Resolve(name string) (asn1.ObjectIdentifier, error)
+
+	// Name returns the object name that owns oid, plus any trailing
+	// index past that object's base OID.
+	Name(oid asn1.ObjectIdentifier) (name string, index []int, err error)
+}
+
+// textualConventionOf looks up the textual convention mib associates
+// with oid, if mib exposes that level of detail.
+func textualConventionOf(mib MIB, oid asn1.ObjectIdentifier) (TextualConvention, bool) {
+	if mib == nil {
+		return NoConvention, false
+	}
+	tc, ok := mib.(interface {
+		TextualConvention(asn1.ObjectIdentifier) (TextualConvention, bool)
+	})
+	if !ok {
+		return NoConvention, false
+	}
+	return tc.TextualConvention(oid)
+}
+
+// Resolve resolves name against mib and returns the corresponding Binding,
+// with a null value, ready to be sent in a Request. It lets callers write
+// snmp.Resolve(mib, "IF-MIB::ifInOctets.2") instead of hardcoding OIDs.
+func Resolve(mib MIB, name string) (Binding, error) {
+	oid, err := mib.Resolve(name)
+	if err != nil {
+		return Binding{}, err
+	}
+	return Binding{Name: oid, Value: null}, nil
+}
+
+// ResolveBindings resolves each of names against mib and appends the
+// resulting Bindings to req.Bindings, so callers can write symbolic names
+// instead of building Bindings by hand. It stops and returns the first
+// error encountered, leaving req.Bindings unmodified.
+func (req *Request) ResolveBindings(mib MIB, names ...string) error {
+	bindings := make([]Binding, 0, len(names))
+	for _, name := range names {
+		b, err := Resolve(mib, name)
+		if err != nil {
+			return err
+		}
+		bindings = append(bindings, b)
+	}
+	req.Bindings = append(req.Bindings, bindings...)
+	return nil
+}
+
+// PrettyName renders b.Name using mib's reverse mapping, for logging, and
+// falls back to the raw numeric OID if mib is nil or cannot resolve it.
+func (b Binding) PrettyName(mib MIB) string {
+	if mib == nil {
+		return b.Name.String()
+	}
+	name, index, err := mib.Name(b.Name)
+	if err != nil {
+		return b.Name.String()
+	}
+	if len(index) == 0 {
+		return name
+	}
+	parts := make([]string, len(index))
+	for i, v := range index {
+		parts[i] = strconv.Itoa(v)
+	}
+	return name + "." + strings.Join(parts, ".")
+}
+
+// TextualConvention identifies an SMIv2 TEXTUAL-CONVENTION with a
+// well-known canonical Go representation.
+type TextualConvention int
+
+// Supported textual conventions.
+const (
+	// NoConvention applies no special conversion.
+	NoConvention TextualConvention = iota
+	// DisplayString converts an OCTET STRING to a Go string.
+	DisplayString
+	// MacAddress converts a 6-byte OCTET STRING to "xx:xx:xx:xx:xx:xx".
+	MacAddress
+	// TimeTicksConvention converts TimeTicks, in hundredths of a
+	// second, to a time.Duration.
+	TimeTicksConvention
+)
+
+// convertTextualConvention converts v to tc's canonical Go form, if v is
+// of the type tc expects.
+func convertTextualConvention(tc TextualConvention, v interface{}) (interface{}, bool) {
+	switch tc {
+	case DisplayString:
+		if b, ok := v.([]byte); ok {
+			return string(b), true
+		}
+	case MacAddress:
+		if b, ok := v.([]byte); ok && len(b) == 6 {
+			return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", b[0], b[1], b[2], b[3], b[4], b[5]), true
+		}
+	case TimeTicksConvention:
+		if n, ok := v.(int); ok {
+			return time.Duration(n) * 10 * time.Millisecond, true
+		}
+	}
+	return nil, false
+}
+
+// StaticMIB is a MIB backed by fixed Go maps, suitable for a small,
+// hand-maintained set of object names.
+type StaticMIB struct {
+	// Objects maps "MODULE::object" names to their base OID.
+	Objects map[string]asn1.ObjectIdentifier
+
+	// TextualConventions maps a base OID, in the dotted-decimal form
+	// returned by asn1.ObjectIdentifier.String, to the textual
+	// convention applied to its values.
+	TextualConventions map[string]TextualConvention
+
+	byOID map[string]string
+}
+
+// Resolve implements MIB.
+func (m *StaticMIB) Resolve(name string) (asn1.ObjectIdentifier, error) {
+	parts := strings.Split(name, ".")
+	for i := len(parts); i > 0; i-- {
+		base, ok := m.Objects[strings.Join(parts[:i], ".")]
+		if !ok {
+			continue
+		}
+		index, err := parseIndex(parts[i:])
+		if err != nil {
+			return nil, err
+		}
+		oid := make(asn1.ObjectIdentifier, 0, len(base)+len(index))
+		oid = append(oid, base...)
+		oid = append(oid, index...)
+		return oid, nil
+	}
+	return nil, fmt.Errorf("snmp: unknown object %q", name)
+}
+
+// Name implements MIB.
+func (m *StaticMIB) Name(oid asn1.ObjectIdentifier) (string, []int, error) {
+	if m.byOID == nil {
+		m.byOID = make(map[string]string, len(m.Objects))
+		for name, base := range m.Objects {
+			m.byOID[base.String()] = name
+		}
+	}
+	for i := len(oid); i > 0; i-- {
+		name, ok := m.byOID[asn1.ObjectIdentifier(oid[:i]).String()]
+		if !ok {
+			continue
+		}
+		return name, append([]int(nil), oid[i:]...), nil
+	}
+	return "", nil, fmt.Errorf("snmp: unknown OID %s", oid.String())
+}
+
+// TextualConvention reports the textual convention associated with oid,
+// if any, for use by convertType.
+func (m *StaticMIB) TextualConvention(oid asn1.ObjectIdentifier) (TextualConvention, bool) {
+	tc, ok := m.TextualConventions[oid.String()]
+	return tc, ok
+}
+
+func parseIndex(parts []string) ([]int, error) {
+	index := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("snmp: invalid index %q: %v", p, err)
+		}
+		index[i] = n
+	}
+	return index, nil
+}
+
+// SMIParser is a MIB built by parsing one or more SMIv2 module files. It
+// resolves OBJECT IDENTIFIER, OBJECT-TYPE, OBJECT-IDENTITY,
+// NOTIFICATION-TYPE and MODULE-IDENTITY assignments into a single OID
+// tree, and embeds a StaticMIB so results are looked up the same way as a
+// hand-built one. Parent names are resolved against every file parsed
+// together and the well-known root nodes; IMPORTS clauses themselves are
+// not read. TEXTUAL-CONVENTION definitions are not parsed: an object gets
+// a textual convention only when its SYNTAX names DisplayString,
+// PhysAddress, MacAddress or TimeTicks.
+type SMIParser struct {
+	StaticMIB
+}
+
+// wellKnownRoots are the standard nodes every SMIv2 module ultimately
+// anchors its OBJECT IDENTIFIER assignments to.
+var wellKnownRoots = map[string]asn1.ObjectIdentifier{
+	"iso":          {1},
+	"org":          {1, 3},
+	"dod":          {1, 3, 6},
+	"internet":     {1, 3, 6, 1},
+	"directory":    {1, 3, 6, 1, 1},
+	"mgmt":         {1, 3, 6, 1, 2},
+	"mib-2":        {1, 3, 6, 1, 2, 1},
+	"experimental": {1, 3, 6, 1, 3},
+	"private":      {1, 3, 6, 1, 4},
+	"enterprises":  {1, 3, 6, 1, 4, 1},
+	"snmpV2":       {1, 3, 6, 1, 6},
+	"snmpModules":  {1, 3, 6, 1, 6, 3},
+}
+
+// smiAssignment is one parsed "name TYPE ... ::= { parent sub }"
+// construct, such as an OBJECT IDENTIFIER or OBJECT-TYPE assignment.
+type smiAssignment struct {
+	module string
+	parent string
+	sub    int
+	tc     TextualConvention
+}
+
+var (
+	moduleRe     = regexp.MustCompile(`(?m)^\s*([A-Za-z][\w-]*)\s+DEFINITIONS\s*::=\s*BEGIN`)
+	assignmentRe = regexp.MustCompile(`(?s)([A-Za-z][\w-]*)\s+(OBJECT IDENTIFIER|OBJECT-TYPE|OBJECT-IDENTITY|NOTIFICATION-TYPE|MODULE-IDENTITY)\b(.*?)::=\s*\{\s*([A-Za-z][\w-]*)\s+(\d+)\s*\}`)
+	syntaxRe     = regexp.MustCompile(`SYNTAX\s+([A-Za-z][\w-]*)`)
+)
+
+// NewSMIParser parses the named SMIv2 module files and returns a MIB with
+// every assignment SMIParser recognizes resolved into a single OID tree.
+func NewSMIParser(files ...string) (*SMIParser, error) {
+	p := &SMIParser{StaticMIB: StaticMIB{
+		Objects:            make(map[string]asn1.ObjectIdentifier),
+		TextualConventions: make(map[string]TextualConvention),
+	}}
+
+	assignments := make(map[string]smiAssignment)
+	for _, file := range files {
+		as, err := parseSMIFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("snmp: parsing %s: %v", file, err)
+		}
+		for name, a := range as {
+			assignments[name] = a
+		}
+	}
+
+	resolved := make(map[string]asn1.ObjectIdentifier, len(wellKnownRoots))
+	for name, oid := range wellKnownRoots {
+		resolved[name] = oid
+	}
+
+	// IMPORTS means an assignment's parent may be defined in another
+	// module file (or a well-known root); resolve to a fixed point so
+	// order across files and within a file doesn't matter.
+	for progress := true; progress && len(assignments) > 0; {
+		progress = false
+		for name, a := range assignments {
+			parent, ok := resolved[a.parent]
+			if !ok {
+				continue
+			}
+			oid := append(append(asn1.ObjectIdentifier{}, parent...), a.sub)
+			resolved[name] = oid
+			p.Objects[a.module+"::"+name] = oid
+			if a.tc != NoConvention {
+				p.TextualConventions[oid.String()] = a.tc
+			}
+			delete(assignments, name)
+			progress = true
+		}
+	}
+	if len(assignments) > 0 {
+		for name := range assignments {
+			return nil, fmt.Errorf("snmp: could not resolve %q: unknown parent node", name)
+		}
+	}
+	return p, nil
+}
+
+// parseSMIFile extracts every OBJECT IDENTIFIER / OBJECT-TYPE-like
+// assignment from an SMIv2 module file.
+func parseSMIFile(path string) (map[string]smiAssignment, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := string(data)
+
+	module := strings.TrimSuffix(strings.ToUpper(path), ".MIB")
+	if m := moduleRe.FindStringSubmatch(text); m != nil {
+		module = m[1]
+	}
+
+	out := make(map[string]smiAssignment)
+	for _, m := range assignmentRe.FindAllStringSubmatch(text, -1) {
+		name, body, parent, subStr := m[1], m[3], m[4], m[5]
+		sub, err := strconv.Atoi(subStr)
+		if err != nil {
+			continue
+		}
+		a := smiAssignment{module: module, parent: parent, sub: sub}
+		if s := syntaxRe.FindStringSubmatch(body); s != nil {
+			a.tc = textualConventionNamed(s[1])
+		}
+		out[name] = a
+	}
+	return out, nil
+}
+
+func textualConventionNamed(name string) TextualConvention {
+	switch name {
+	case "DisplayString":
+		return DisplayString
+	case "PhysAddress", "MacAddress":
+		return MacAddress
+	case "TimeTicks":
+		return TimeTicksConvention
+	default:
+		return NoConvention
+	}
+}
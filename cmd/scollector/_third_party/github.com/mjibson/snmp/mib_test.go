@@ -0,0 +1,151 @@
+package snmp
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMIBSource = `IF-TEST-MIB DEFINITIONS ::= BEGIN
+
+ifTest OBJECT IDENTIFIER ::= { mib-2 2 }
+
+ifTestInOctets OBJECT-TYPE
+    SYNTAX      INTEGER
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Test counter."
+    ::= { ifTest 10 }
+
+ifTestDescr OBJECT-TYPE
+    SYNTAX      DisplayString
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Test description."
+    ::= { ifTest 2 }
+
+END
+`
+
+func writeTestMIB(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "mibtest")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "IF-TEST-MIB.mib")
+	if err := ioutil.WriteFile(path, []byte(testMIBSource), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestSMIParserResolve(t *testing.T) {
+	mib, err := NewSMIParser(writeTestMIB(t))
+	if err != nil {
+		t.Fatalf("NewSMIParser: %v", err)
+	}
+
+	oid, err := mib.Resolve("IF-TEST-MIB::ifTestInOctets.2")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	want := "1.3.6.1.2.1.2.10.2"
+	if got := oid.String(); got != want {
+		t.Errorf("Resolve(ifTestInOctets.2) = %s, want %s", got, want)
+	}
+
+	name, index, err := mib.Name(oid)
+	if err != nil {
+		t.Fatalf("Name: %v", err)
+	}
+	if name != "IF-TEST-MIB::ifTestInOctets" {
+		t.Errorf("Name = %q, want IF-TEST-MIB::ifTestInOctets", name)
+	}
+	if len(index) != 1 || index[0] != 2 {
+		t.Errorf("index = %v, want [2]", index)
+	}
+}
+
+func TestSMIParserTextualConvention(t *testing.T) {
+	mib, err := NewSMIParser(writeTestMIB(t))
+	if err != nil {
+		t.Fatalf("NewSMIParser: %v", err)
+	}
+	oid, err := mib.Resolve("IF-TEST-MIB::ifTestDescr")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	tc, ok := mib.TextualConvention(oid)
+	if !ok || tc != DisplayString {
+		t.Errorf("TextualConvention = (%v, %v), want (DisplayString, true)", tc, ok)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	mib, err := NewSMIParser(writeTestMIB(t))
+	if err != nil {
+		t.Fatalf("NewSMIParser: %v", err)
+	}
+	b, err := Resolve(mib, "IF-TEST-MIB::ifTestInOctets.2")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if b.Name.String() != "1.3.6.1.2.1.2.10.2" {
+		t.Errorf("Binding.Name = %s, want 1.3.6.1.2.1.2.10.2", b.Name.String())
+	}
+}
+
+func TestRequestResolveBindings(t *testing.T) {
+	mib, err := NewSMIParser(writeTestMIB(t))
+	if err != nil {
+		t.Fatalf("NewSMIParser: %v", err)
+	}
+	req := &Request{ID: 1, Type: "Get"}
+	if err := req.ResolveBindings(mib, "IF-TEST-MIB::ifTestInOctets.2", "IF-TEST-MIB::ifTestDescr.1"); err != nil {
+		t.Fatalf("ResolveBindings: %v", err)
+	}
+	if len(req.Bindings) != 2 {
+		t.Fatalf("len(Bindings) = %d, want 2", len(req.Bindings))
+	}
+	if req.Bindings[0].Name.String() != "1.3.6.1.2.1.2.10.2" {
+		t.Errorf("Bindings[0].Name = %s, want 1.3.6.1.2.1.2.10.2", req.Bindings[0].Name.String())
+	}
+
+	if err := req.ResolveBindings(mib, "IF-TEST-MIB::doesNotExist"); err == nil {
+		t.Error("ResolveBindings with an unknown name succeeded, want an error")
+	}
+}
+
+// TestConvertTypePerCallMIB verifies convertType applies whichever mib is
+// passed to a given call, rather than consulting any shared package-level
+// state -- the same oid/value pair converts differently depending only on
+// the mib argument, so two callers (e.g. two Servers for different
+// devices) can safely use different MIBs concurrently.
+func TestConvertTypePerCallMIB(t *testing.T) {
+	oid := asn1.ObjectIdentifier{1, 3, 6, 1, 2, 1, 2, 2, 1, 6, 1} // ifPhysAddress.1
+	mac := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+
+	macMIB := &StaticMIB{TextualConventions: map[string]TextualConvention{
+		oid.String(): MacAddress,
+	}}
+	got := convertType(macMIB, oid, mac)
+	want := "00:11:22:33:44:55"
+	if got != want {
+		t.Errorf("convertType with a MAC-aware mib = %v, want %q", got, want)
+	}
+
+	// The identical call with a mib that has no convention for this oid
+	// must not pick up the first mib's conversion.
+	unrelatedMIB := &StaticMIB{}
+	if got, ok := convertType(unrelatedMIB, oid, mac).([]byte); !ok || !bytes.Equal(got, mac) {
+		t.Errorf("convertType with an unrelated mib = %v, want the raw bytes unconverted", got)
+	}
+	if got, ok := convertType(nil, oid, mac).([]byte); !ok || !bytes.Equal(got, mac) {
+		t.Errorf("convertType with a nil mib = %v, want the raw bytes unconverted", got)
+	}
+}
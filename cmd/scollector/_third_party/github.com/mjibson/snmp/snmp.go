@@ -2,10 +2,12 @@
 package snmp
 
 import (
+	"context"
 	"encoding/asn1"
 	"fmt"
 	"math/rand"
 	"net"
+	"reflect"
 	"time"
 )
 
@@ -23,8 +25,12 @@ type Binding struct {
 	Value asn1.RawValue
 }
 
-// unmarshal stores in v the value part of binding b.
-func (b *Binding) unmarshal(v interface{}) error {
+// unmarshal stores in v the value part of binding b, consulting mib (which
+// may be nil) for any textual convention to apply to the result. mib is
+// taken as a parameter rather than a package-level default so that a
+// process talking to multiple devices can use a different MIB per call
+// without racing on shared state.
+func (b *Binding) unmarshal(mib MIB, v interface{}) error {
 	convertClass(&b.Value)
 	_, err := asn1.Unmarshal(b.Value.FullBytes, v)
 	if err != nil {
@@ -34,7 +40,13 @@ func (b *Binding) unmarshal(v interface{}) error {
 		}
 		return err
 	}
-	v = convertType(v)
+	if converted := convertType(mib, b.Name, reflect.ValueOf(v).Elem().Interface()); converted != nil {
+		cv := reflect.ValueOf(converted)
+		ev := reflect.ValueOf(v).Elem()
+		if cv.Type().AssignableTo(ev.Type()) {
+			ev.Set(cv)
+		}
+	}
 	return nil
 }
 
@@ -65,8 +77,15 @@ func convertClass(v *asn1.RawValue) {
 }
 
 // convertType converts value in SNMP response to a Go type that is
-// easier to manipulate.
-func convertType(v interface{}) interface{} {
+// easier to manipulate. When mib is non-nil and reports a textual
+// convention for oid, that convention's canonical Go form is preferred
+// over the generic conversion below.
+func convertType(mib MIB, oid asn1.ObjectIdentifier, v interface{}) interface{} {
+	if tc, ok := textualConventionOf(mib, oid); ok {
+		if converted, ok := convertTextualConvention(tc, v); ok {
+			return converted
+		}
+	}
 	switch v := v.(type) {
 	case []byte:
 		s, ok := toString(v)
@@ -149,6 +168,11 @@ type Response struct {
 // A RoundTripper must be safe for concurrent use by multiple goroutines.
 type RoundTripper interface {
 	RoundTrip(*Request) (*Response, error)
+
+	// RoundTripContext is like RoundTrip but honors ctx for cancellation
+	// and deadline propagation in addition to any transport-level
+	// timeout and retry settings.
+	RoundTripContext(ctx context.Context, req *Request) (*Response, error)
 }
 
 // Transport is an implementation of RoundTripper that supports SNMPv2
@@ -156,6 +180,18 @@ type RoundTripper interface {
 type Transport struct {
 	Conn      net.Conn
 	Community string
+
+	// Timeout bounds how long a single transmission attempt waits for a
+	// reply. It defaults to 5 seconds.
+	Timeout time.Duration
+
+	// Retries is the number of additional attempts made, retransmitting
+	// with the same request ID, after an attempt times out.
+	Retries int
+
+	// RetryBackoff is the delay before the first retry; the delay
+	// doubles after each subsequent attempt. It defaults to Timeout.
+	RetryBackoff time.Duration
 }
 
 func newTransport(host, community string) (*Transport, error) {
@@ -171,16 +207,124 @@ func newTransport(host, community string) (*Transport, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Transport{conn, community}, nil
+	return &Transport{Conn: conn, Community: community}, nil
 }
 
 // RoundTrip implements the RoundTripper interface.
 func (tr *Transport) RoundTrip(req *Request) (*Response, error) {
+	return tr.RoundTripContext(context.Background(), req)
+}
+
+// RoundTripContext implements the RoundTripper interface. It retransmits
+// req, keeping the same request ID, up to tr.Retries times with
+// exponential backoff when an attempt times out, and aborts as soon as
+// ctx is done.
+func (tr *Transport) RoundTripContext(ctx context.Context, req *Request) (*Response, error) {
 	for i := range req.Bindings {
 		req.Bindings[i].Value = null
 	}
-	var buf []byte
-	var err error
+	buf, err := marshalV2Request(req, tr.Community)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := tr.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	backoff := tr.RetryBackoff
+	if backoff <= 0 {
+		backoff = timeout
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= tr.Retries; attempt++ {
+		if attempt > 0 {
+			observeRetry(ctx, attempt)
+			select {
+			case <-time.After(backoff << uint(attempt-1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := tr.Conn.Write(buf); err != nil {
+			return nil, err
+		}
+		resp, err := tr.readResponse(ctx, req.ID, timeout)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// retryObserverKey is the context key under which a retry-observation
+// callback may be stored, allowing instrumentation such as
+// TracingRoundTripper to record each retransmission as a span event.
+type retryObserverKey struct{}
+
+// withRetryObserver returns a copy of ctx that invokes fn immediately
+// before each retransmission attempt (attempt counts from 1).
+func withRetryObserver(ctx context.Context, fn func(attempt int)) context.Context {
+	return context.WithValue(ctx, retryObserverKey{}, fn)
+}
+
+func observeRetry(ctx context.Context, attempt int) {
+	if fn, ok := ctx.Value(retryObserverKey{}).(func(attempt int)); ok {
+		fn(attempt)
+	}
+}
+
+// readResponse reads replies until one matching id arrives, the deadline
+// formed from timeout and ctx's deadline passes, or ctx is done. Replies
+// for a different request ID are discarded, since they may be late
+// arrivals for a previous retry.
+func (tr *Transport) readResponse(ctx context.Context, id int32, timeout time.Duration) (*Response, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	buf := make([]byte, 10000, 10000)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := tr.Conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, err := tr.Conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == len(buf) {
+			return nil, fmt.Errorf("response too big")
+		}
+		var p struct {
+			Version   int
+			Community []byte
+			Data      struct {
+				RequestID   int32
+				ErrorStatus int
+				ErrorIndex  int
+				Bindings    []Binding
+			} `asn1:"tag:2"`
+		}
+		if _, err = asn1.Unmarshal(buf[:n], &p); err != nil {
+			return nil, err
+		}
+		if p.Data.RequestID != id {
+			continue
+		}
+		return &Response{p.Data.RequestID, p.Data.ErrorStatus, p.Data.ErrorIndex, p.Data.Bindings}, nil
+	}
+}
+
+// marshalV2Request BER-encodes req as an SNMPv2c packet using community.
+func marshalV2Request(req *Request, community string) ([]byte, error) {
 	switch req.Type {
 	case "Get":
 		var p struct {
@@ -194,10 +338,10 @@ func (tr *Transport) RoundTrip(req *Request) (*Response, error) {
 			} `asn1:"application,tag:0"`
 		}
 		p.Version = 1
-		p.Community = []byte(tr.Community)
+		p.Community = []byte(community)
 		p.Data.RequestID = req.ID
 		p.Data.Bindings = req.Bindings
-		buf, err = asn1.Marshal(p)
+		return asn1.Marshal(p)
 	case "GetNext":
 		var p struct {
 			Version   int
@@ -210,10 +354,10 @@ func (tr *Transport) RoundTrip(req *Request) (*Response, error) {
 			} `asn1:"application,tag:1"`
 		}
 		p.Version = 1
-		p.Community = []byte(tr.Community)
+		p.Community = []byte(community)
 		p.Data.RequestID = req.ID
 		p.Data.Bindings = req.Bindings
-		buf, err = asn1.Marshal(p)
+		return asn1.Marshal(p)
 	case "GetBulk":
 		var p struct {
 			Version   int
@@ -226,47 +370,15 @@ func (tr *Transport) RoundTrip(req *Request) (*Response, error) {
 			} `asn1:"application,tag:5"`
 		}
 		p.Version = 1
-		p.Community = []byte(tr.Community)
+		p.Community = []byte(community)
 		p.Data.RequestID = req.ID
 		p.Data.NonRepeaters = 0
 		p.Data.MaxRepetitions = req.MaxRepetitions
 		p.Data.Bindings = req.Bindings
-		buf, err = asn1.Marshal(p)
+		return asn1.Marshal(p)
 	default:
 		panic("unsupported type " + req.Type)
 	}
-	if err != nil {
-		return nil, err
-	}
-	if _, err := tr.Conn.Write(buf); err != nil {
-		return nil, err
-	}
-	buf = make([]byte, 10000, 10000)
-	if err := tr.Conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
-		return nil, err
-	}
-	n, err := tr.Conn.Read(buf)
-	if err != nil {
-		return nil, err
-	}
-	if n == len(buf) {
-		return nil, fmt.Errorf("response too big")
-	}
-	var p struct {
-		Version   int
-		Community []byte
-		Data      struct {
-			RequestID   int32
-			ErrorStatus int
-			ErrorIndex  int
-			Bindings    []Binding
-		} `asn1:"tag:2"`
-	}
-	if _, err = asn1.Unmarshal(buf[:n], &p); err != nil {
-		return nil, err
-	}
-	resp := &Response{p.Data.RequestID, p.Data.ErrorStatus, p.Data.ErrorIndex, p.Data.Bindings}
-	return resp, nil
 }
 
 // check checks the response PDU for basic correctness.
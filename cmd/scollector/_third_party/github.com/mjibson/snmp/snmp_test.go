@@ -0,0 +1,189 @@
+package snmp
+
+import (
+	"context"
+	"encoding/asn1"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// timeoutError implements net.Error with Timeout() true, simulating a
+// read deadline expiring without a matching reply having arrived.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// scriptedConn is a net.Conn whose Read calls replay a fixed script of
+// byte slices, for exercising Transport.RoundTripContext's retry and
+// cancellation logic without a real socket. A nil script entry (or
+// running past the end of the script) yields timeoutError, standing in
+// for a read deadline expiring.
+type scriptedConn struct {
+	mu         sync.Mutex
+	script     [][]byte
+	reads      int
+	writes     [][]byte
+	remoteAddr net.Addr
+}
+
+func (c *scriptedConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reads >= len(c.script) || c.script[c.reads] == nil {
+		c.reads++
+		return 0, timeoutError{}
+	}
+	data := c.script[c.reads]
+	c.reads++
+	return copy(b, data), nil
+}
+
+func (c *scriptedConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes = append(c.writes, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (c *scriptedConn) Close() error                     { return nil }
+func (c *scriptedConn) LocalAddr() net.Addr              { return nil }
+func (c *scriptedConn) RemoteAddr() net.Addr             { return c.remoteAddr }
+func (c *scriptedConn) SetDeadline(time.Time) error      { return nil }
+func (c *scriptedConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *scriptedConn) SetWriteDeadline(time.Time) error { return nil }
+
+// responsePDU marshals a minimal context-specifically tagged Response-PDU
+// (tag 2) carrying id, matching the wire format Transport.readResponse
+// expects.
+func responsePDU(id int32) []byte {
+	var p struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:2"`
+	}
+	p.Data.RequestID = id
+	buf, err := asn1.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// writtenRequestIDs decodes the request ID out of each of conn's recorded
+// writes, in order.
+func writtenRequestIDs(t *testing.T, conn *scriptedConn) []int32 {
+	t.Helper()
+	var ids []int32
+	for _, w := range conn.writes {
+		var p struct {
+			Version   int
+			Community []byte
+			Data      struct {
+				RequestID   int32
+				ErrorStatus int
+				ErrorIndex  int
+				Bindings    []Binding
+			} `asn1:"application,tag:0"`
+		}
+		if _, err := asn1.Unmarshal(w, &p); err != nil {
+			t.Fatalf("unmarshal written request: %v", err)
+		}
+		ids = append(ids, p.Data.RequestID)
+	}
+	return ids
+}
+
+// TestRoundTripContextRetriesWithBackoff verifies a timed-out attempt is
+// retransmitted with the same request ID up to Retries times, with
+// increasing delay between attempts, and that the eventual matching
+// reply is returned.
+func TestRoundTripContextRetriesWithBackoff(t *testing.T) {
+	const id = 42
+	conn := &scriptedConn{script: [][]byte{nil, nil, responsePDU(id)}}
+	tr := &Transport{
+		Conn:         conn,
+		Timeout:      time.Second,
+		Retries:      2,
+		RetryBackoff: 5 * time.Millisecond,
+	}
+
+	start := time.Now()
+	resp, err := tr.RoundTripContext(context.Background(), &Request{ID: id, Type: "Get"})
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("RoundTripContext: %v", err)
+	}
+	if resp.ID != id {
+		t.Errorf("resp.ID = %d, want %d", resp.ID, id)
+	}
+
+	ids := writtenRequestIDs(t, conn)
+	if len(ids) != 3 {
+		t.Fatalf("got %d transmissions, want 3 (1 initial + 2 retries): %v", len(ids), ids)
+	}
+	for _, got := range ids {
+		if got != id {
+			t.Errorf("retransmission used request ID %d, want %d", got, id)
+		}
+	}
+	// Backoff doubles each attempt: 5ms then 10ms, so at least 15ms
+	// should elapse between the first and last transmission.
+	if elapsed < 14*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least ~15ms given a doubling 5ms backoff over 2 retries", elapsed)
+	}
+}
+
+// TestRoundTripContextDiscardsMismatchedID verifies a reply carrying a
+// different request ID — a late arrival for some other transaction — is
+// discarded rather than accepted, and readResponse keeps reading until it
+// sees the matching one.
+func TestRoundTripContextDiscardsMismatchedID(t *testing.T) {
+	const id = 7
+	conn := &scriptedConn{script: [][]byte{responsePDU(id + 1), responsePDU(id)}}
+	tr := &Transport{Conn: conn, Timeout: time.Second}
+
+	resp, err := tr.RoundTripContext(context.Background(), &Request{ID: id, Type: "Get"})
+	if err != nil {
+		t.Fatalf("RoundTripContext: %v", err)
+	}
+	if resp.ID != id {
+		t.Errorf("resp.ID = %d, want %d", resp.ID, id)
+	}
+	if len(conn.writes) != 1 {
+		t.Errorf("got %d transmissions, want 1 (mismatched ID should not trigger a retransmission)", len(conn.writes))
+	}
+}
+
+// TestRoundTripContextCancelAbortsImmediately verifies a cancelled ctx
+// aborts a pending retry without waiting out the full backoff delay.
+func TestRoundTripContextCancelAbortsImmediately(t *testing.T) {
+	conn := &scriptedConn{script: [][]byte{nil}}
+	tr := &Transport{
+		Conn:         conn,
+		Timeout:      time.Second,
+		Retries:      5,
+		RetryBackoff: 200 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := tr.RoundTripContext(ctx, &Request{ID: 1, Type: "Get"})
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("RoundTripContext succeeded, want an error from the cancelled ctx")
+	}
+	if elapsed >= tr.RetryBackoff {
+		t.Errorf("elapsed = %v, want well under the %v backoff (ctx should abort the wait)", elapsed, tr.RetryBackoff)
+	}
+}
@@ -0,0 +1,74 @@
+package snmp
+
+import (
+	"context"
+	"fmt"
+)
+
+// Span is the minimal tracing span surface TracingRoundTripper needs. It is
+// satisfied by a thin adapter over whatever tracing SDK the caller has
+// vendored; this package has no tracing dependency of its own.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	AddEvent(name string)
+	SetError(msg string)
+	End()
+}
+
+// Tracer starts a Span, abstracting over whatever tracing SDK the caller
+// has vendored.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// TracingRoundTripper wraps a RoundTripper, starting a span for each SNMP
+// transaction with snmp.request_id, snmp.type, snmp.community, snmp.peer
+// and snmp.varbind_count attributes, recording snmp.error_status on a
+// server error response, and logging each retry attempt as a span event.
+// snmp.community and snmp.peer are set only when the wrapped RoundTripper
+// is a *Transport.
+type TracingRoundTripper struct {
+	RoundTripper
+	tracer Tracer
+}
+
+// NewTracingRoundTripper wraps rt, starting a span on tracer for every
+// transaction. tracer is typically a small adapter over a vendored tracing
+// SDK's own tracer type.
+func NewTracingRoundTripper(rt RoundTripper, tracer Tracer) *TracingRoundTripper {
+	return &TracingRoundTripper{RoundTripper: rt, tracer: tracer}
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *TracingRoundTripper) RoundTrip(req *Request) (*Response, error) {
+	return t.RoundTripContext(context.Background(), req)
+}
+
+// RoundTripContext implements the RoundTripper interface.
+func (t *TracingRoundTripper) RoundTripContext(ctx context.Context, req *Request) (*Response, error) {
+	ctx, span := t.tracer.Start(ctx, "snmp."+req.Type)
+	defer span.End()
+
+	span.SetAttribute("snmp.request_id", req.ID)
+	span.SetAttribute("snmp.type", req.Type)
+	span.SetAttribute("snmp.varbind_count", len(req.Bindings))
+	if tr, ok := t.RoundTripper.(*Transport); ok {
+		span.SetAttribute("snmp.community", tr.Community)
+		if tr.Conn != nil {
+			span.SetAttribute("snmp.peer", tr.Conn.RemoteAddr().String())
+		}
+	}
+
+	ctx = withRetryObserver(ctx, func(attempt int) {
+		span.AddEvent(fmt.Sprintf("retry %d", attempt))
+	})
+
+	resp, err := t.RoundTripper.RoundTripContext(ctx, req)
+	switch {
+	case err != nil:
+		span.SetError(err.Error())
+	case resp.ErrorStatus != 0:
+		span.SetAttribute("snmp.error_status", errorStatus(resp.ErrorStatus).String())
+	}
+	return resp, err
+}
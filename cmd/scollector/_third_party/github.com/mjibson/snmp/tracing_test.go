@@ -0,0 +1,184 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeSpan records the calls TracingRoundTripper makes on it, for
+// assertions, standing in for a real tracing SDK's span type.
+type fakeSpan struct {
+	attrs  map[string]interface{}
+	events []string
+	errMsg string
+	ended  bool
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) {
+	if s.attrs == nil {
+		s.attrs = make(map[string]interface{})
+	}
+	s.attrs[key] = value
+}
+func (s *fakeSpan) AddEvent(name string) { s.events = append(s.events, name) }
+func (s *fakeSpan) SetError(msg string)  { s.errMsg = msg }
+func (s *fakeSpan) End()                 { s.ended = true }
+
+// fakeTracer is a Tracer that hands out fakeSpans and keeps the last one
+// started, so a test can inspect it after a RoundTripContext call returns.
+type fakeTracer struct {
+	lastName string
+	lastSpan *fakeSpan
+}
+
+func (tr *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &fakeSpan{}
+	tr.lastName = name
+	tr.lastSpan = span
+	return ctx, span
+}
+
+// stubRoundTripper is a RoundTripper whose RoundTripContext returns a
+// canned Response and error, for exercising TracingRoundTripper without a
+// real Transport.
+type stubRoundTripper struct {
+	resp *Response
+	err  error
+}
+
+func (s *stubRoundTripper) RoundTrip(req *Request) (*Response, error) {
+	return s.RoundTripContext(context.Background(), req)
+}
+
+func (s *stubRoundTripper) RoundTripContext(ctx context.Context, req *Request) (*Response, error) {
+	return s.resp, s.err
+}
+
+// TestTracingRoundTripperSetsBaseAttributes verifies the request_id, type
+// and varbind_count attributes are set regardless of which RoundTripper is
+// wrapped, and that the span is ended.
+func TestTracingRoundTripperSetsBaseAttributes(t *testing.T) {
+	tracer := &fakeTracer{}
+	rt := NewTracingRoundTripper(&stubRoundTripper{resp: &Response{ID: 9}}, tracer)
+
+	req := &Request{ID: 9, Type: "Get", Bindings: []Binding{{}, {}}}
+	if _, err := rt.RoundTripContext(context.Background(), req); err != nil {
+		t.Fatalf("RoundTripContext: %v", err)
+	}
+
+	if tracer.lastName != "snmp.Get" {
+		t.Errorf("span name = %q, want %q", tracer.lastName, "snmp.Get")
+	}
+	span := tracer.lastSpan
+	if got := span.attrs["snmp.request_id"]; got != int32(9) {
+		t.Errorf("snmp.request_id = %v, want 9", got)
+	}
+	if got := span.attrs["snmp.type"]; got != "Get" {
+		t.Errorf("snmp.type = %v, want Get", got)
+	}
+	if got := span.attrs["snmp.varbind_count"]; got != 2 {
+		t.Errorf("snmp.varbind_count = %v, want 2", got)
+	}
+	if !span.ended {
+		t.Error("span was never ended")
+	}
+}
+
+// TestTracingRoundTripperSetsCommunityAndPeer verifies that, when the
+// wrapped RoundTripper is a *Transport, the community string and the
+// connection's remote address are recorded as attributes.
+func TestTracingRoundTripperSetsCommunityAndPeer(t *testing.T) {
+	conn := &scriptedConn{
+		script:     [][]byte{responsePDU(1)},
+		remoteAddr: &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 161},
+	}
+	tr := &Transport{Conn: conn, Community: "public", Timeout: time.Second}
+	tracer := &fakeTracer{}
+	rt := NewTracingRoundTripper(tr, tracer)
+
+	if _, err := rt.RoundTripContext(context.Background(), &Request{ID: 1, Type: "Get"}); err != nil {
+		t.Fatalf("RoundTripContext: %v", err)
+	}
+
+	span := tracer.lastSpan
+	if got := span.attrs["snmp.community"]; got != "public" {
+		t.Errorf("snmp.community = %v, want public", got)
+	}
+	if got := span.attrs["snmp.peer"]; got != "192.0.2.1:161" {
+		t.Errorf("snmp.peer = %v, want 192.0.2.1:161", got)
+	}
+}
+
+// TestTracingRoundTripperRecordsRetriesAsEvents verifies that retransmission
+// attempts made by the wrapped Transport surface as span events via
+// withRetryObserver, rather than going unobserved.
+func TestTracingRoundTripperRecordsRetriesAsEvents(t *testing.T) {
+	const id = 5
+	conn := &scriptedConn{
+		script:     [][]byte{nil, responsePDU(id)},
+		remoteAddr: &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 161},
+	}
+	tr := &Transport{
+		Conn:         conn,
+		Timeout:      time.Second,
+		Retries:      1,
+		RetryBackoff: time.Millisecond,
+	}
+	tracer := &fakeTracer{}
+	rt := NewTracingRoundTripper(tr, tracer)
+
+	if _, err := rt.RoundTripContext(context.Background(), &Request{ID: id, Type: "Get"}); err != nil {
+		t.Fatalf("RoundTripContext: %v", err)
+	}
+
+	span := tracer.lastSpan
+	want := []string{"retry 1"}
+	if fmt.Sprint(span.events) != fmt.Sprint(want) {
+		t.Errorf("events = %v, want %v", span.events, want)
+	}
+}
+
+// TestTracingRoundTripperRecordsErrorStatus verifies a server error
+// response is recorded as the snmp.error_status attribute.
+func TestTracingRoundTripperRecordsErrorStatus(t *testing.T) {
+	tracer := &fakeTracer{}
+	resp := &Response{ID: 1, ErrorStatus: 5}
+	rt := NewTracingRoundTripper(&stubRoundTripper{resp: resp}, tracer)
+
+	if _, err := rt.RoundTripContext(context.Background(), &Request{ID: 1, Type: "Get"}); err != nil {
+		t.Fatalf("RoundTripContext: %v", err)
+	}
+
+	span := tracer.lastSpan
+	if got := span.attrs["snmp.error_status"]; got != errorStatus(5).String() {
+		t.Errorf("snmp.error_status = %v, want %v", got, errorStatus(5).String())
+	}
+	if span.errMsg != "" {
+		t.Errorf("SetError was called with %q, want no call for a server error response", span.errMsg)
+	}
+}
+
+// TestTracingRoundTripperRecordsTransportError verifies a transport-level
+// error from the wrapped RoundTripper is recorded via SetError.
+func TestTracingRoundTripperRecordsTransportError(t *testing.T) {
+	tracer := &fakeTracer{}
+	wantErr := errors.New("boom")
+	rt := NewTracingRoundTripper(&stubRoundTripper{err: wantErr}, tracer)
+
+	_, err := rt.RoundTripContext(context.Background(), &Request{ID: 1, Type: "Get"})
+	if err != wantErr {
+		t.Fatalf("RoundTripContext err = %v, want %v", err, wantErr)
+	}
+
+	span := tracer.lastSpan
+	if span.errMsg != wantErr.Error() {
+		t.Errorf("SetError message = %q, want %q", span.errMsg, wantErr.Error())
+	}
+	if _, ok := span.attrs["snmp.error_status"]; ok {
+		t.Error("snmp.error_status was set, want it absent on a transport error")
+	}
+}
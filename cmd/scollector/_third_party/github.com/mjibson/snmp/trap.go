@@ -0,0 +1,252 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Standard varbinds carried by every SNMPv2 notification, per RFC 3416
+// section 4.2.6.
+var (
+	oidSysUpTime   = asn1.ObjectIdentifier{1, 3, 6, 1, 2, 1, 1, 3, 0}
+	oidSNMPTrapOID = asn1.ObjectIdentifier{1, 3, 6, 1, 6, 3, 1, 1, 4, 1, 0}
+)
+
+// TrapPDU represents a received SNMPv2 trap or inform notification.
+type TrapPDU struct {
+	RequestID int32
+	SysUpTime uint32
+	TrapOID   asn1.ObjectIdentifier
+	Bindings  []Binding
+}
+
+// TrapHandler responds to an inbound trap or inform PDU received from src.
+type TrapHandler interface {
+	ServeSNMP(src net.Addr, pdu *TrapPDU)
+}
+
+// TrapHandlerFunc adapts an ordinary function to a TrapHandler.
+type TrapHandlerFunc func(src net.Addr, pdu *TrapPDU)
+
+// ServeSNMP calls f.
+func (f TrapHandlerFunc) ServeSNMP(src net.Addr, pdu *TrapPDU) { f(src, pdu) }
+
+// Server listens for SNMPv2 traps and informs and dispatches them to
+// Handler. InformRequests are acknowledged automatically with a Response
+// PDU carrying the same request ID and no error.
+type Server struct {
+	Addr      string
+	Handler   TrapHandler
+	Community string
+
+	// MIB, if non-nil, is passed to the decoding of the sysUpTime.0 and
+	// snmpTrapOID.0 varbinds into TrapPDU's SysUpTime and TrapOID fields.
+	// The values in TrapPDU.Bindings reach Handler undecoded, as raw
+	// asn1.RawValues, with no textual-convention conversion applied.
+	MIB MIB
+
+	conn *net.UDPConn
+}
+
+// ListenAndServe listens on s.Addr, defaulting to ":162", and serves
+// incoming traps and informs until it encounters an error reading from
+// the socket.
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":162"
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	buf := make([]byte, 10000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return err
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		go s.handle(src, data)
+	}
+}
+
+// handle decodes a single inbound packet and dispatches it to s.Handler,
+// acknowledging informs first. Packets whose community string does not
+// match s.Community are dropped silently, as the SNMPv2c wire format
+// offers no way to report an authentication failure back to an untrusted
+// sender. A zero-value s.Community accepts any community string.
+func (s *Server) handle(src net.Addr, data []byte) {
+	pdu, isInform, id, community, err := parseTrap(s.MIB, data)
+	if err != nil {
+		return
+	}
+	if s.Community != "" && community != s.Community {
+		return
+	}
+	if isInform {
+		s.respond(src, id)
+	}
+	if s.Handler != nil {
+		s.Handler.ServeSNMP(src, pdu)
+	}
+}
+
+// respond sends an empty-error Response PDU for the inform with the given
+// request ID, as required by RFC 3416 section 4.2.7.
+func (s *Server) respond(src net.Addr, id int32) {
+	var p struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:2"`
+	}
+	p.Version = 1
+	p.Community = []byte(s.Community)
+	p.Data.RequestID = id
+	buf, err := asn1.Marshal(p)
+	if err != nil {
+		return
+	}
+	s.conn.WriteTo(buf, src)
+}
+
+// parseTrap decodes data as either a TrapV2 (tag 7) or InformRequest (tag
+// 6) PDU, reporting which it was, the request ID and community string it
+// carried. Like the Response-PDU decoded in Transport.readResponse, these
+// PDUs arrive tagged context-specific, not application. mib, which may be
+// nil, is passed through to bindTrapPDU for textual-convention conversion.
+func parseTrap(mib MIB, data []byte) (pdu *TrapPDU, isInform bool, id int32, community string, err error) {
+	var trap struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:7"`
+	}
+	if _, err := asn1.Unmarshal(data, &trap); err == nil {
+		pdu, err := bindTrapPDU(mib, trap.Data.RequestID, trap.Data.Bindings)
+		return pdu, false, trap.Data.RequestID, string(trap.Community), err
+	}
+
+	var inform struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:6"`
+	}
+	if _, err := asn1.Unmarshal(data, &inform); err == nil {
+		pdu, err := bindTrapPDU(mib, inform.Data.RequestID, inform.Data.Bindings)
+		return pdu, true, inform.Data.RequestID, string(inform.Community), err
+	}
+
+	return nil, false, 0, "", fmt.Errorf("not a trap or inform PDU")
+}
+
+// bindTrapPDU lifts the standard sysUpTime.0 and snmpTrapOID.0 varbinds
+// into typed fields on a TrapPDU, leaving the raw bindings accessible too.
+// mib, which may be nil, is consulted for textual-convention conversion.
+func bindTrapPDU(mib MIB, id int32, bindings []Binding) (*TrapPDU, error) {
+	pdu := &TrapPDU{RequestID: id, Bindings: bindings}
+	for i := range bindings {
+		b := &bindings[i]
+		switch {
+		case oidEqual(b.Name, oidSysUpTime):
+			// encoding/asn1 only unmarshals into signed integer kinds, and
+			// TimeTicks is a 32-bit unsigned value that can exceed
+			// math.MaxInt32 (uptimes past ~248.5 days), so decode into an
+			// int64 and widen rather than int32.
+			var v int64
+			if err := b.unmarshal(mib, &v); err == nil {
+				pdu.SysUpTime = uint32(v)
+			}
+		case oidEqual(b.Name, oidSNMPTrapOID):
+			var v asn1.ObjectIdentifier
+			if err := b.unmarshal(mib, &v); err == nil {
+				pdu.TrapOID = v
+			}
+		}
+	}
+	return pdu, nil
+}
+
+func oidEqual(a, b asn1.ObjectIdentifier) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// muxEntry pairs a registered trap OID prefix with its handler.
+type muxEntry struct {
+	prefix  asn1.ObjectIdentifier
+	handler TrapHandler
+}
+
+// ServeMux is a TrapHandler that dispatches to handlers registered by
+// trap OID prefix, analogous to net/http.ServeMux.
+type ServeMux struct {
+	mu      sync.RWMutex
+	entries []muxEntry
+}
+
+// NewServeMux allocates a new ServeMux.
+func NewServeMux() *ServeMux {
+	return &ServeMux{}
+}
+
+// Handle registers handler to serve traps whose TrapOID falls under
+// prefix. When multiple registered prefixes match, the longest wins.
+func (mux *ServeMux) Handle(prefix asn1.ObjectIdentifier, handler TrapHandler) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.entries = append(mux.entries, muxEntry{prefix, handler})
+}
+
+// HandleFunc registers fn to serve traps whose TrapOID falls under prefix.
+func (mux *ServeMux) HandleFunc(prefix asn1.ObjectIdentifier, fn func(net.Addr, *TrapPDU)) {
+	mux.Handle(prefix, TrapHandlerFunc(fn))
+}
+
+// ServeSNMP implements TrapHandler, dispatching pdu to the most specific
+// registered handler whose prefix matches pdu.TrapOID.
+func (mux *ServeMux) ServeSNMP(src net.Addr, pdu *TrapPDU) {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	var best TrapHandler
+	bestLen := -1
+	for _, e := range mux.entries {
+		if hasPrefix(pdu.TrapOID, e.prefix) && len(e.prefix) > bestLen {
+			best = e.handler
+			bestLen = len(e.prefix)
+		}
+	}
+	if best != nil {
+		best.ServeSNMP(src, pdu)
+	}
+}
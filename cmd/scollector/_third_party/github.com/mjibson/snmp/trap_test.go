@@ -0,0 +1,126 @@
+package snmp
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+// TestParseTrapContextSpecificTag verifies that parseTrap accepts a
+// TrapV2 PDU encoded the way real agents send it: the PDU tagged
+// context-specific (not application), matching the convention this
+// package already uses for incoming Response-PDUs.
+func TestParseTrapContextSpecificTag(t *testing.T) {
+	var p struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:7"`
+	}
+	p.Version = 1
+	p.Community = []byte("public")
+	p.Data.RequestID = 42
+	upTime, err := asn1.MarshalWithParams(5, "application,tag:3")
+	if err != nil {
+		t.Fatalf("marshal sysUpTime: %v", err)
+	}
+	p.Data.Bindings = []Binding{
+		{Name: oidSysUpTime, Value: asn1.RawValue{FullBytes: upTime}},
+	}
+	buf, err := asn1.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	pdu, isInform, id, community, err := parseTrap(nil, buf)
+	if err != nil {
+		t.Fatalf("parseTrap: %v", err)
+	}
+	if isInform {
+		t.Errorf("isInform = true, want false for a TrapV2 PDU")
+	}
+	if id != 42 {
+		t.Errorf("RequestID = %d, want 42", id)
+	}
+	if community != "public" {
+		t.Errorf("community = %q, want %q", community, "public")
+	}
+	if pdu.SysUpTime != 5 {
+		t.Errorf("SysUpTime = %d, want 5", pdu.SysUpTime)
+	}
+}
+
+// TestParseTrapSysUpTimeOverflowsInt32 verifies sysUpTime.0 decodes
+// correctly for uptimes past math.MaxInt32 centiseconds (~248.5 days),
+// which a naive int32 decode would reject with "integer too large".
+func TestParseTrapSysUpTimeOverflowsInt32(t *testing.T) {
+	var p struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:7"`
+	}
+	p.Version = 1
+	p.Community = []byte("public")
+	p.Data.RequestID = 42
+	const uptime = 3000000000 // ~347 days in centiseconds, > math.MaxInt32
+	upTime, err := asn1.MarshalWithParams(int64(uptime), "application,tag:3")
+	if err != nil {
+		t.Fatalf("marshal sysUpTime: %v", err)
+	}
+	p.Data.Bindings = []Binding{
+		{Name: oidSysUpTime, Value: asn1.RawValue{FullBytes: upTime}},
+	}
+	buf, err := asn1.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	pdu, _, _, _, err := parseTrap(nil, buf)
+	if err != nil {
+		t.Fatalf("parseTrap: %v", err)
+	}
+	if pdu.SysUpTime != uptime {
+		t.Errorf("SysUpTime = %d, want %d", pdu.SysUpTime, uptime)
+	}
+}
+
+// TestParseTrapInform verifies InformRequest PDUs (tag 6) are recognized
+// and reported as such.
+func TestParseTrapInform(t *testing.T) {
+	var p struct {
+		Version   int
+		Community []byte
+		Data      struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		} `asn1:"tag:6"`
+	}
+	p.Version = 1
+	p.Community = []byte("public")
+	p.Data.RequestID = 7
+	buf, err := asn1.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	_, isInform, id, _, err := parseTrap(nil, buf)
+	if err != nil {
+		t.Fatalf("parseTrap: %v", err)
+	}
+	if !isInform {
+		t.Errorf("isInform = false, want true for an InformRequest PDU")
+	}
+	if id != 7 {
+		t.Errorf("RequestID = %d, want 7", id)
+	}
+}
@@ -0,0 +1,795 @@
+package snmp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net"
+	"sync"
+	"time"
+)
+
+// AuthProtocol identifies a USM authentication algorithm.
+type AuthProtocol int
+
+// Supported authentication protocols.
+const (
+	NoAuth AuthProtocol = iota
+	AuthMD5
+	AuthSHA
+)
+
+// PrivProtocol identifies a USM privacy (encryption) algorithm.
+type PrivProtocol int
+
+// Supported privacy protocols.
+const (
+	NoPriv PrivProtocol = iota
+	PrivDES
+	PrivAES128
+)
+
+// SecurityParameters holds the User-based Security Model (USM) credentials
+// used to authenticate and, optionally, encrypt SNMPv3 messages, as defined
+// in RFC 3414.
+type SecurityParameters struct {
+	Username     string
+	AuthProtocol AuthProtocol
+	AuthPassword string
+	PrivProtocol PrivProtocol
+	PrivPassword string
+}
+
+// validate reports a configuration error for a SecurityParameters that
+// would make localizeKey silently derive a nil key, e.g. a privacy
+// protocol with no password to localize, rather than letting
+// encryptDES/encryptAES panic later on a short key slice.
+func (sec SecurityParameters) validate() error {
+	if sec.AuthProtocol != NoAuth && sec.AuthPassword == "" {
+		return fmt.Errorf("snmpv3: AuthProtocol set without an AuthPassword")
+	}
+	if sec.PrivProtocol != NoPriv && sec.AuthProtocol == NoAuth {
+		return fmt.Errorf("snmpv3: PrivProtocol requires an AuthProtocol")
+	}
+	if sec.PrivProtocol != NoPriv && sec.PrivPassword == "" {
+		return fmt.Errorf("snmpv3: PrivProtocol set without a PrivPassword")
+	}
+	return nil
+}
+
+// usmSecurityModel is the value of msgSecurityModel for the User-based
+// Security Model, as registered in RFC 3411.
+const usmSecurityModel = 3
+
+// Message flag bits, per RFC 3412 section 6.3.
+const (
+	flagAuth       byte = 0x01
+	flagPriv       byte = 0x02
+	flagReportable byte = 0x04
+)
+
+// usmEngine tracks the authoritative engine state required to authenticate
+// and encrypt messages sent to a particular SNMPv3 peer.
+type usmEngine struct {
+	id      []byte
+	boots   int32
+	time    int32
+	synced  time.Time
+	authKey []byte
+	privKey []byte
+}
+
+// stale reports whether the cached engine boots/time have drifted outside
+// the ±150 second window allowed by RFC 3414 section 3.2, and so must be
+// rediscovered before the next authenticated request.
+func (e *usmEngine) stale() bool {
+	if e == nil || len(e.id) == 0 {
+		return true
+	}
+	return time.Since(e.synced).Seconds() > 150
+}
+
+// V3Transport is an implementation of RoundTripper that speaks SNMPv3,
+// using the User-based Security Model (USM) for authentication and
+// privacy, as defined in RFC 3414.
+type V3Transport struct {
+	Conn     net.Conn
+	Security SecurityParameters
+
+	// Timeout, Retries and RetryBackoff have the same meaning as the
+	// identically named Transport fields.
+	Timeout      time.Duration
+	Retries      int
+	RetryBackoff time.Duration
+
+	mu     sync.Mutex
+	engine *usmEngine
+}
+
+// NewV3Transport dials host and returns a V3Transport authenticated with
+// sec. The authoritative engine is discovered lazily on the first request.
+func NewV3Transport(host string, sec SecurityParameters) (*V3Transport, error) {
+	hostport := host
+	if _, _, err := net.SplitHostPort(hostport); err != nil {
+		hostport = host + ":161"
+	}
+	addr, err := net.ResolveUDPAddr("udp", hostport)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &V3Transport{Conn: conn, Security: sec}, nil
+}
+
+// scopedPDU is the plaintext payload of an SNMPv3 message: a PDU together
+// with the context in which it is to be processed, per RFC 3412 section 6.1.
+type scopedPDU struct {
+	ContextEngineID []byte
+	ContextName     []byte
+	Data            asn1.RawValue
+}
+
+// usmSecurityParameters is the USM-specific content of msgSecurityParameters.
+// It is BER-encoded on its own and carried as an OCTET STRING inside the
+// message header, per RFC 3414 section 2.4.
+type usmSecurityParameters struct {
+	AuthoritativeEngineID    []byte
+	AuthoritativeEngineBoots int32
+	AuthoritativeEngineTime  int32
+	UserName                 []byte
+	AuthenticationParameters []byte
+	PrivacyParameters        []byte
+}
+
+// v3Message is the top-level SNMPv3 message envelope, per RFC 3412 section 6.
+type v3Message struct {
+	Version   int
+	MsgID     int32
+	MaxSize   int32
+	Flags     []byte
+	SecModel  int
+	SecParams []byte
+	Data      asn1.RawValue
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (tr *V3Transport) RoundTrip(req *Request) (*Response, error) {
+	return tr.RoundTripContext(context.Background(), req)
+}
+
+// RoundTripContext implements the RoundTripper interface, retransmitting
+// req with the same request ID up to tr.Retries times with exponential
+// backoff on timeout, and aborting as soon as ctx is done.
+func (tr *V3Transport) RoundTripContext(ctx context.Context, req *Request) (*Response, error) {
+	engine, err := tr.engineFor(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("snmpv3: engine discovery: %v", err)
+	}
+
+	for i := range req.Bindings {
+		req.Bindings[i].Value = null
+	}
+
+	auth := tr.Security.AuthProtocol != NoAuth
+	priv := auth && tr.Security.PrivProtocol != NoPriv
+
+	buf, err := tr.marshalMessage(engine, req, auth, priv)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := tr.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	backoff := tr.RetryBackoff
+	if backoff <= 0 {
+		backoff = timeout
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= tr.Retries; attempt++ {
+		if attempt > 0 {
+			observeRetry(ctx, attempt)
+			select {
+			case <-time.After(backoff << uint(attempt-1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := tr.Conn.Write(buf); err != nil {
+			return nil, err
+		}
+		resp, err := tr.readResponse(ctx, engine, req.ID, timeout)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// engineFor returns tr's current authoritative engine state, discovering
+// or rediscovering it if stale. Only the brief read/write of tr.engine is
+// done under tr.mu; the discovery round-trip itself runs without holding
+// the lock, so it does not block other in-flight requests that already
+// have a fresh engine from proceeding concurrently.
+func (tr *V3Transport) engineFor(ctx context.Context) (*usmEngine, error) {
+	tr.mu.Lock()
+	engine := tr.engine
+	tr.mu.Unlock()
+	if !engine.stale() {
+		return engine, nil
+	}
+
+	engine, err := tr.discoverEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tr.mu.Lock()
+	tr.engine = engine
+	tr.mu.Unlock()
+	return engine, nil
+}
+
+// marshalMessage builds a complete, wire-ready SNMPv3 message for req
+// against engine, applying authentication and privacy as requested.
+func (tr *V3Transport) marshalMessage(engine *usmEngine, req *Request, auth, priv bool) ([]byte, error) {
+	pdu, err := marshalPDU(req)
+	if err != nil {
+		return nil, err
+	}
+	sp := scopedPDU{
+		ContextEngineID: engine.id,
+		Data:            asn1.RawValue{FullBytes: pdu},
+	}
+	plain, err := asn1.Marshal(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	data := plain
+	privParams := []byte{}
+	if priv {
+		data, privParams, err = encryptScopedPDU(engine, tr.Security.PrivProtocol, plain)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flags := byte(0)
+	if auth {
+		flags |= flagAuth
+	}
+	if priv {
+		flags |= flagPriv
+	}
+	flags |= flagReportable
+
+	usm := usmSecurityParameters{
+		AuthoritativeEngineID:    engine.id,
+		AuthoritativeEngineBoots: engine.boots,
+		AuthoritativeEngineTime:  engine.time,
+		UserName:                 []byte(tr.Security.Username),
+		PrivacyParameters:        privParams,
+	}
+	if auth {
+		usm.AuthenticationParameters = make([]byte, 12)
+	}
+	secParams, err := asn1.Marshal(usm)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := v3Message{
+		Version:   3,
+		MsgID:     <-nextID,
+		MaxSize:   65507,
+		Flags:     []byte{flags},
+		SecModel:  usmSecurityModel,
+		SecParams: secParams,
+	}
+	if priv {
+		msg.Data = asn1.RawValue{FullBytes: mustOctetString(data)}
+	} else {
+		msg.Data = asn1.RawValue{FullBytes: data}
+	}
+
+	buf, err := asn1.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if auth {
+		offset, err := authParamsOffset(msg, usm)
+		if err != nil {
+			return nil, err
+		}
+		buf, err = authenticate(buf, offset, engine.authKey, tr.Security.AuthProtocol)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// discoverEngine learns the peer's authoritative engine ID, boots and time
+// by sending a Get with an empty security name, per RFC 3414 section 4. It
+// honors ctx's deadline and tr.Timeout the same way readResponse does,
+// rather than hardcoding its own.
+func (tr *V3Transport) discoverEngine(ctx context.Context) (*usmEngine, error) {
+	if err := tr.Security.validate(); err != nil {
+		return nil, err
+	}
+
+	msg := v3Message{
+		Version:  3,
+		MsgID:    <-nextID,
+		MaxSize:  65507,
+		Flags:    []byte{flagReportable},
+		SecModel: usmSecurityModel,
+	}
+	usm := usmSecurityParameters{}
+	secParams, err := asn1.Marshal(usm)
+	if err != nil {
+		return nil, err
+	}
+	msg.SecParams = secParams
+
+	sp := scopedPDU{
+		Data: asn1.RawValue{FullBytes: emptyGetBytes(<-nextID)},
+	}
+	plain, err := asn1.Marshal(sp)
+	if err != nil {
+		return nil, err
+	}
+	msg.Data = asn1.RawValue{FullBytes: plain}
+
+	buf, err := asn1.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := tr.Conn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	timeout := tr.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	rbuf := make([]byte, 10000)
+	if err := tr.Conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	n, err := tr.Conn.Read(rbuf)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply v3Message
+	if _, err := asn1.Unmarshal(rbuf[:n], &reply); err != nil {
+		return nil, err
+	}
+	var rusm usmSecurityParameters
+	if _, err := asn1.Unmarshal(reply.SecParams, &rusm); err != nil {
+		return nil, fmt.Errorf("decoding security parameters: %v", err)
+	}
+	if len(rusm.AuthoritativeEngineID) == 0 {
+		return nil, fmt.Errorf("peer did not report an authoritative engine ID")
+	}
+
+	e := &usmEngine{
+		id:     rusm.AuthoritativeEngineID,
+		boots:  rusm.AuthoritativeEngineBoots,
+		time:   rusm.AuthoritativeEngineTime,
+		synced: time.Now(),
+	}
+	if tr.Security.AuthProtocol != NoAuth {
+		e.authKey = localizeKey(tr.Security.AuthPassword, e.id, authHash(tr.Security.AuthProtocol))
+	}
+	if tr.Security.PrivProtocol != NoPriv {
+		e.privKey = localizeKey(tr.Security.PrivPassword, e.id, authHash(tr.Security.AuthProtocol))
+	}
+	return e, nil
+}
+
+// readResponse reads replies until one carrying a PDU with request ID id
+// arrives, the deadline formed from timeout and ctx's deadline passes, or
+// ctx is done. Mismatched replies are discarded, since they may be late
+// arrivals for a previous retry.
+func (tr *V3Transport) readResponse(ctx context.Context, engine *usmEngine, id int32, timeout time.Duration) (*Response, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	buf := make([]byte, 10000)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := tr.Conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, err := tr.Conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		resp, respID, err := tr.parseResponse(engine, buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		if respID != id {
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// parseResponse decodes a raw v3 message into a Response, returning the
+// request ID carried by its PDU so callers can match it against a pending
+// request before accepting it.
+func (tr *V3Transport) parseResponse(engine *usmEngine, raw []byte) (*Response, int32, error) {
+	var msg v3Message
+	if _, err := asn1.Unmarshal(raw, &msg); err != nil {
+		return nil, 0, err
+	}
+
+	scoped := msg.Data.FullBytes
+	if tr.Security.PrivProtocol != NoPriv {
+		var usm usmSecurityParameters
+		if _, err := asn1.Unmarshal(msg.SecParams, &usm); err != nil {
+			return nil, 0, err
+		}
+		var enc []byte
+		if _, err := asn1.Unmarshal(msg.Data.FullBytes, &enc); err != nil {
+			return nil, 0, err
+		}
+		var err error
+		scoped, err = decryptScopedPDU(engine, tr.Security.PrivProtocol, enc, usm.PrivacyParameters)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var sp scopedPDU
+	if _, err := asn1.Unmarshal(scoped, &sp); err != nil {
+		return nil, 0, err
+	}
+
+	var p struct {
+		RequestID   int32
+		ErrorStatus int
+		ErrorIndex  int
+		Bindings    []Binding
+	}
+	if _, err := asn1.UnmarshalWithParams(sp.Data.FullBytes, &p, "tag:2"); err != nil {
+		if _, rerr := asn1.UnmarshalWithParams(sp.Data.FullBytes, &p, "tag:8"); rerr == nil {
+			return nil, 0, fmt.Errorf("snmpv3: received report PDU (engine boots/time likely out of sync): %+v", p.Bindings)
+		}
+		return nil, 0, err
+	}
+	return &Response{p.RequestID, p.ErrorStatus, p.ErrorIndex, p.Bindings}, p.RequestID, nil
+}
+
+// marshalPDU encodes req's bindings into a context-specifically tagged
+// PDU, as carried inside a ScopedPDU (RFC 3412 section 6.1 defines the
+// PDU types as an implicit CHOICE of context-specific tags, not
+// application).
+func marshalPDU(req *Request) ([]byte, error) {
+	switch req.Type {
+	case "Get", "GetNext":
+		var p struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		}
+		p.RequestID = req.ID
+		p.Bindings = req.Bindings
+		tag := 0
+		if req.Type == "GetNext" {
+			tag = 1
+		}
+		return asn1.MarshalWithParams(p, fmt.Sprintf("tag:%d", tag))
+	case "GetBulk":
+		var p struct {
+			RequestID      int32
+			NonRepeaters   int
+			MaxRepetitions int
+			Bindings       []Binding
+		}
+		p.RequestID = req.ID
+		p.NonRepeaters = req.NonRepeaters
+		p.MaxRepetitions = req.MaxRepetitions
+		p.Bindings = req.Bindings
+		return asn1.MarshalWithParams(p, "tag:5")
+	default:
+		panic("unsupported type " + req.Type)
+	}
+}
+
+// emptyGetBytes builds the body of a Get PDU carrying no bindings, used
+// for engine discovery.
+func emptyGetBytes(id int32) []byte {
+	var p struct {
+		RequestID   int32
+		ErrorStatus int
+		ErrorIndex  int
+		Bindings    []Binding
+	}
+	p.RequestID = id
+	buf, _ := asn1.MarshalWithParams(p, "tag:0")
+	return buf
+}
+
+// mustOctetString wraps b as a BER OCTET STRING.
+func mustOctetString(b []byte) []byte {
+	buf, err := asn1.Marshal(b)
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// authHash returns the hash constructor for a, defaulting to MD5 when
+// unspecified, since the privacy key derivation reuses the auth protocol's
+// digest per RFC 3414 section 2.6.
+func authHash(a AuthProtocol) func() hash.Hash {
+	if a == AuthSHA {
+		return sha1.New
+	}
+	return md5.New
+}
+
+// localizeKey implements the password-to-key algorithm of RFC 3414
+// appendix A.2: the password is expanded to 1MB and digested, then the
+// digest is localized to a specific engine by hashing
+// digest || engineID || digest.
+func localizeKey(password string, engineID []byte, h func() hash.Hash) []byte {
+	if password == "" {
+		return nil
+	}
+	hasher := h()
+	pw := []byte(password)
+	var block [64]byte
+	for written := 0; written < 1048576; written += 64 {
+		for i := range block {
+			block[i] = pw[(written+i)%len(pw)]
+		}
+		hasher.Write(block[:])
+	}
+	digest := hasher.Sum(nil)
+
+	hasher = h()
+	hasher.Write(digest)
+	hasher.Write(engineID)
+	hasher.Write(digest)
+	return hasher.Sum(nil)
+}
+
+// authenticate computes the HMAC-96 authentication code over the fully
+// marshaled message and patches it into the 12-byte AuthenticationParameters
+// field at offset, per RFC 3414 section 6.3.
+func authenticate(buf []byte, offset int, key []byte, proto AuthProtocol) ([]byte, error) {
+	if offset < 0 || offset+12 > len(buf) {
+		return nil, fmt.Errorf("snmpv3: authentication parameters offset %d out of range", offset)
+	}
+	patched := append([]byte(nil), buf...)
+	mac := hmac.New(authHash(proto), key)
+	mac.Write(patched)
+	code := mac.Sum(nil)[:12]
+	copy(patched[offset:offset+12], code)
+	return patched, nil
+}
+
+// authParamsOffset computes the byte offset, within the message produced by
+// asn1.Marshal(msg) once msg.SecParams holds asn1.Marshal(usm), of the
+// 12-byte content of usm.AuthenticationParameters. It derives the offset
+// from the BER encoding length of every field that precedes
+// AuthenticationParameters, rather than searching the marshaled message for
+// a byte pattern that could coincidentally also appear inside msg.Data's
+// ciphertext.
+func authParamsOffset(msg v3Message, usm usmSecurityParameters) (int, error) {
+	engIDTLV, err := asn1.Marshal(usm.AuthoritativeEngineID)
+	if err != nil {
+		return 0, err
+	}
+	bootsTLV, err := asn1.Marshal(usm.AuthoritativeEngineBoots)
+	if err != nil {
+		return 0, err
+	}
+	timeTLV, err := asn1.Marshal(usm.AuthoritativeEngineTime)
+	if err != nil {
+		return 0, err
+	}
+	userTLV, err := asn1.Marshal(usm.UserName)
+	if err != nil {
+		return 0, err
+	}
+	authTLV, err := asn1.Marshal(usm.AuthenticationParameters)
+	if err != nil {
+		return 0, err
+	}
+	privTLV, err := asn1.Marshal(usm.PrivacyParameters)
+	if err != nil {
+		return 0, err
+	}
+	usmContentLen := len(engIDTLV) + len(bootsTLV) + len(timeTLV) + len(userTLV) + len(authTLV) + len(privTLV)
+	usmHeaderLen, err := berSequenceHeaderLen(usmContentLen)
+	if err != nil {
+		return 0, err
+	}
+
+	verTLV, err := asn1.Marshal(msg.Version)
+	if err != nil {
+		return 0, err
+	}
+	idTLV, err := asn1.Marshal(msg.MsgID)
+	if err != nil {
+		return 0, err
+	}
+	maxTLV, err := asn1.Marshal(msg.MaxSize)
+	if err != nil {
+		return 0, err
+	}
+	flagsTLV, err := asn1.Marshal(msg.Flags)
+	if err != nil {
+		return 0, err
+	}
+	secModelTLV, err := asn1.Marshal(msg.SecModel)
+	if err != nil {
+		return 0, err
+	}
+	secParamsTLV, err := asn1.Marshal(msg.SecParams)
+	if err != nil {
+		return 0, err
+	}
+	secParamsFieldHeaderLen := len(secParamsTLV) - len(msg.SecParams)
+	authFieldHeaderLen := len(authTLV) - len(usm.AuthenticationParameters)
+
+	msgContentLen := len(verTLV) + len(idTLV) + len(maxTLV) + len(flagsTLV) + len(secModelTLV) + len(secParamsTLV) + len(msg.Data.FullBytes)
+	msgHeaderLen, err := berSequenceHeaderLen(msgContentLen)
+	if err != nil {
+		return 0, err
+	}
+
+	offset := msgHeaderLen +
+		len(verTLV) + len(idTLV) + len(maxTLV) + len(flagsTLV) + len(secModelTLV) +
+		secParamsFieldHeaderLen + usmHeaderLen +
+		len(engIDTLV) + len(bootsTLV) + len(timeTLV) + len(userTLV) +
+		authFieldHeaderLen
+	return offset, nil
+}
+
+// berSequenceHeaderLen returns the number of bytes a SEQUENCE's own tag and
+// length occupy when its content is contentLen bytes long, by marshaling a
+// content-sized placeholder and measuring the encoding the asn1 package
+// itself produces rather than reimplementing BER's short/long length rules.
+func berSequenceHeaderLen(contentLen int) (int, error) {
+	full, err := asn1.Marshal(asn1.RawValue{Class: 0, Tag: 16, IsCompound: true, Bytes: make([]byte, contentLen)})
+	if err != nil {
+		return 0, err
+	}
+	return len(full) - contentLen, nil
+}
+
+// encryptScopedPDU encrypts plain under the engine's privacy key and
+// returns the ciphertext together with the msgPrivacyParameters salt.
+func encryptScopedPDU(e *usmEngine, proto PrivProtocol, plain []byte) (ciphertext, privParams []byte, err error) {
+	switch proto {
+	case PrivDES:
+		return encryptDES(e, plain)
+	case PrivAES128:
+		return encryptAES(e, plain)
+	default:
+		return plain, nil, nil
+	}
+}
+
+func decryptScopedPDU(e *usmEngine, proto PrivProtocol, ciphertext, privParams []byte) ([]byte, error) {
+	switch proto {
+	case PrivDES:
+		return decryptDES(e, ciphertext, privParams)
+	case PrivAES128:
+		return decryptAES(e, ciphertext, privParams)
+	default:
+		return ciphertext, nil
+	}
+}
+
+// pad appends zero bytes to b up to a multiple of the DES block size.
+func pad(b []byte) []byte {
+	if n := len(b) % des.BlockSize; n != 0 {
+		b = append(b, make([]byte, des.BlockSize-n)...)
+	}
+	return b
+}
+
+func encryptDES(e *usmEngine, plain []byte) ([]byte, []byte, error) {
+	block, err := des.NewCipher(e.privKey[:8])
+	if err != nil {
+		return nil, nil, err
+	}
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	iv := make([]byte, 8)
+	for i := range iv {
+		iv[i] = e.privKey[8+i] ^ salt[i]
+	}
+	plain = pad(plain)
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return out, salt, nil
+}
+
+func decryptDES(e *usmEngine, ciphertext, salt []byte) ([]byte, error) {
+	block, err := des.NewCipher(e.privKey[:8])
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, 8)
+	for i := range iv {
+		iv[i] = e.privKey[8+i] ^ salt[i]
+	}
+	out := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+	return out, nil
+}
+
+// privIV builds the 16-byte IV for AES-128-CFB from the engine boots/time
+// and an 8-byte local salt, per RFC 3826 section 3.1.1.
+func privIV(e *usmEngine, salt []byte) []byte {
+	iv := make([]byte, 16)
+	binary.BigEndian.PutUint32(iv[0:4], uint32(e.boots))
+	binary.BigEndian.PutUint32(iv[4:8], uint32(e.time))
+	copy(iv[8:], salt)
+	return iv
+}
+
+func encryptAES(e *usmEngine, plain []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(e.privKey[:16])
+	if err != nil {
+		return nil, nil, err
+	}
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	out := make([]byte, len(plain))
+	cipher.NewCFBEncrypter(block, privIV(e, salt)).XORKeyStream(out, plain)
+	return out, salt, nil
+}
+
+func decryptAES(e *usmEngine, ciphertext, salt []byte) ([]byte, error) {
+	block, err := aes.NewCipher(e.privKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(ciphertext))
+	cipher.NewCFBDecrypter(block, privIV(e, salt)).XORKeyStream(out, ciphertext)
+	return out, nil
+}
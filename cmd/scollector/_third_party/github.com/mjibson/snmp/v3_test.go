@@ -0,0 +1,607 @@
+package snmp
+
+import (
+	"bytes"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/asn1"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestMarshalPDUContextSpecificTag verifies that the PDU embedded in a
+// ScopedPDU is tagged context-specific, not application, matching RFC
+// 3412 section 6.1.
+func TestMarshalPDUContextSpecificTag(t *testing.T) {
+	buf, err := marshalPDU(&Request{ID: 1, Type: "Get"})
+	if err != nil {
+		t.Fatalf("marshalPDU: %v", err)
+	}
+	// A context-specific constructed tag 0 encodes as 0xa0; an
+	// application-class tag 0 would encode as 0x60.
+	if buf[0] != 0xa0 {
+		t.Errorf("leading tag byte = %#x, want 0xa0 (context-specific, constructed, tag 0)", buf[0])
+	}
+}
+
+// TestEmptyGetBytesNoDoubleWrap verifies the discovery Get PDU is a
+// single context-specific SEQUENCE, not a SEQUENCE nested inside another
+// tag 0 wrapper.
+func TestEmptyGetBytesNoDoubleWrap(t *testing.T) {
+	buf := emptyGetBytes(9)
+	if buf[0] != 0xa0 {
+		t.Fatalf("leading tag byte = %#x, want 0xa0", buf[0])
+	}
+	// The PDU's first field (requestID) is a plain INTEGER, so the byte
+	// right after the tag+length header must be 0x02, not another
+	// nested 0x30 SEQUENCE.
+	content := buf[2:]
+	if content[0] != 0x02 {
+		t.Errorf("PDU content starts with %#x, want 0x02 (INTEGER requestID), got nested SEQUENCE instead", content[0])
+	}
+}
+
+// TestParseResponseRoundTrip verifies parseResponse decodes a
+// context-specifically tagged Response-PDU (tag 2), as a real agent
+// sends it.
+func TestParseResponseRoundTrip(t *testing.T) {
+	var pdu struct {
+		RequestID   int32
+		ErrorStatus int
+		ErrorIndex  int
+		Bindings    []Binding
+	}
+	pdu.RequestID = 123
+	pduBytes, err := asn1.MarshalWithParams(pdu, "tag:2")
+	if err != nil {
+		t.Fatalf("marshal PDU: %v", err)
+	}
+
+	sp := scopedPDU{Data: asn1.RawValue{FullBytes: pduBytes}}
+	spBytes, err := asn1.Marshal(sp)
+	if err != nil {
+		t.Fatalf("marshal scopedPDU: %v", err)
+	}
+
+	secParams, err := asn1.Marshal(usmSecurityParameters{})
+	if err != nil {
+		t.Fatalf("marshal security parameters: %v", err)
+	}
+	msg := v3Message{
+		Version:   3,
+		SecParams: secParams,
+		Data:      asn1.RawValue{FullBytes: spBytes},
+	}
+	raw, err := asn1.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+
+	tr := &V3Transport{}
+	resp, id, err := tr.parseResponse(nil, raw)
+	if err != nil {
+		t.Fatalf("parseResponse: %v", err)
+	}
+	if id != 123 || resp.ID != 123 {
+		t.Errorf("request ID = %d (resp.ID = %d), want 123", id, resp.ID)
+	}
+}
+
+// TestParseResponseReportPDU verifies a Report-PDU (tag 8), sent when
+// the engine boots/time are out of sync, is reported as an error rather
+// than failing with an opaque tag-mismatch.
+func TestParseResponseReportPDU(t *testing.T) {
+	var pdu struct {
+		RequestID   int32
+		ErrorStatus int
+		ErrorIndex  int
+		Bindings    []Binding
+	}
+	pduBytes, err := asn1.MarshalWithParams(pdu, "tag:8")
+	if err != nil {
+		t.Fatalf("marshal PDU: %v", err)
+	}
+	sp := scopedPDU{Data: asn1.RawValue{FullBytes: pduBytes}}
+	spBytes, err := asn1.Marshal(sp)
+	if err != nil {
+		t.Fatalf("marshal scopedPDU: %v", err)
+	}
+	secParams, err := asn1.Marshal(usmSecurityParameters{})
+	if err != nil {
+		t.Fatalf("marshal security parameters: %v", err)
+	}
+	msg := v3Message{Version: 3, SecParams: secParams, Data: asn1.RawValue{FullBytes: spBytes}}
+	raw, err := asn1.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+
+	tr := &V3Transport{}
+	if _, _, err := tr.parseResponse(nil, raw); err == nil {
+		t.Error("parseResponse succeeded on a report PDU, want an error")
+	}
+}
+
+// TestLocalizeKey checks the basic properties RFC 3414 appendix A.2
+// requires of the password-to-key algorithm: deterministic per
+// password+engine, sized to the digest, and distinct across engines.
+func TestLocalizeKey(t *testing.T) {
+	engineA := []byte{0x80, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05}
+	engineB := []byte{0x80, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x06}
+
+	md5Key := localizeKey("maplesyrup", engineA, md5.New)
+	if len(md5Key) != md5.Size {
+		t.Errorf("md5 key length = %d, want %d", len(md5Key), md5.Size)
+	}
+	if again := localizeKey("maplesyrup", engineA, md5.New); string(again) != string(md5Key) {
+		t.Error("localizeKey is not deterministic for the same password and engine")
+	}
+	if other := localizeKey("maplesyrup", engineB, md5.New); string(other) == string(md5Key) {
+		t.Error("localizeKey produced the same key for two different engine IDs")
+	}
+
+	shaKey := localizeKey("maplesyrup", engineA, sha1.New)
+	if len(shaKey) != sha1.Size {
+		t.Errorf("sha1 key length = %d, want %d", len(shaKey), sha1.Size)
+	}
+
+	if localizeKey("", engineA, md5.New) != nil {
+		t.Error("localizeKey(\"\", ...) should return nil, not derive a key from an empty password")
+	}
+}
+
+// TestSecurityParametersValidate ensures a privacy protocol configured
+// without a password is rejected before it can reach the short-slice
+// key derivation in encryptDES/encryptAES.
+func TestSecurityParametersValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		sec     SecurityParameters
+		wantErr bool
+	}{
+		{"no security", SecurityParameters{}, false},
+		{"auth only", SecurityParameters{AuthProtocol: AuthMD5, AuthPassword: "x"}, false},
+		{"auth without password", SecurityParameters{AuthProtocol: AuthMD5}, true},
+		{"priv without password", SecurityParameters{AuthProtocol: AuthMD5, AuthPassword: "x", PrivProtocol: PrivAES128}, true},
+		{"priv without auth", SecurityParameters{PrivProtocol: PrivAES128, PrivPassword: "x"}, true},
+		{"fully configured", SecurityParameters{AuthProtocol: AuthSHA, AuthPassword: "x", PrivProtocol: PrivDES, PrivPassword: "y"}, false},
+	}
+	for _, c := range cases {
+		err := c.sec.validate()
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: validate() error = %v, wantErr %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+// TestAuthParamsOffset verifies authParamsOffset locates exactly the
+// AuthenticationParameters content within the marshaled message, and that
+// authenticate patches that location (and only that location) with a
+// verifiable HMAC.
+func TestAuthParamsOffset(t *testing.T) {
+	usm := usmSecurityParameters{
+		AuthoritativeEngineID:    []byte{0x80, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03},
+		AuthoritativeEngineBoots: 4,
+		AuthoritativeEngineTime:  9999,
+		UserName:                 []byte("tester"),
+		AuthenticationParameters: make([]byte, 12),
+	}
+	secParams, err := asn1.Marshal(usm)
+	if err != nil {
+		t.Fatalf("marshal usm: %v", err)
+	}
+	msg := v3Message{
+		Version:   3,
+		MsgID:     1,
+		MaxSize:   65507,
+		Flags:     []byte{flagAuth | flagReportable},
+		SecModel:  usmSecurityModel,
+		SecParams: secParams,
+		Data:      asn1.RawValue{FullBytes: emptyGetBytes(1)},
+	}
+	buf, err := asn1.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal msg: %v", err)
+	}
+
+	offset, err := authParamsOffset(msg, usm)
+	if err != nil {
+		t.Fatalf("authParamsOffset: %v", err)
+	}
+	if offset < 0 || offset+12 > len(buf) {
+		t.Fatalf("offset %d out of range for a %d-byte message", offset, len(buf))
+	}
+	for i := 0; i < 12; i++ {
+		if buf[offset+i] != 0 {
+			t.Fatalf("buf[%d:%d+12] = %x, want all-zero placeholder", offset, offset, buf[offset:offset+12])
+		}
+	}
+
+	key := []byte("sharedsecret")
+	patched, err := authenticate(buf, offset, key, AuthMD5)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+
+	// Everything outside the 12-byte auth field must be unchanged.
+	for i := range buf {
+		if i >= offset && i < offset+12 {
+			continue
+		}
+		if patched[i] != buf[i] {
+			t.Fatalf("authenticate modified byte %d outside the auth field", i)
+		}
+	}
+
+	// Recompute the HMAC over the message with the auth field zeroed
+	// again, the way the receiver would, and confirm it matches what was
+	// patched in.
+	verifyBuf := append([]byte(nil), patched...)
+	copy(verifyBuf[offset:offset+12], make([]byte, 12))
+	mac := hmac.New(authHash(AuthMD5), key)
+	mac.Write(verifyBuf)
+	want := mac.Sum(nil)[:12]
+	got := patched[offset : offset+12]
+	if string(got) != string(want) {
+		t.Errorf("patched auth code = %x, want %x", got, want)
+	}
+}
+
+// TestEncryptDecryptDESRoundTrip verifies decryptDES recovers exactly what
+// encryptDES produced, including its zero-padding to the DES block size.
+func TestEncryptDecryptDESRoundTrip(t *testing.T) {
+	e := &usmEngine{privKey: []byte("0123456789abcdef")}
+	plain := []byte("a scopedPDU that needs padding to a block boundary")
+
+	ciphertext, salt, err := encryptDES(e, plain)
+	if err != nil {
+		t.Fatalf("encryptDES: %v", err)
+	}
+	if len(ciphertext)%des.BlockSize != 0 {
+		t.Fatalf("ciphertext length %d is not a multiple of the DES block size", len(ciphertext))
+	}
+
+	got, err := decryptDES(e, ciphertext, salt)
+	if err != nil {
+		t.Fatalf("decryptDES: %v", err)
+	}
+	if want := pad(append([]byte(nil), plain...)); !bytes.Equal(got, want) {
+		t.Errorf("decryptDES = %x, want %x (zero-padded plaintext)", got, want)
+	}
+
+	// A different 8-byte salt means a different IV, so it must not
+	// recover the same plaintext.
+	wrongSalt := append([]byte(nil), salt...)
+	wrongSalt[0] ^= 0xff
+	if got, err := decryptDES(e, ciphertext, wrongSalt); err == nil && bytes.Equal(got, pad(plain)) {
+		t.Error("decryptDES recovered the plaintext with the wrong salt, IV should differ")
+	}
+}
+
+// TestEncryptDecryptAESRoundTrip verifies decryptAES recovers exactly what
+// encryptAES produced, and that the boots/time values folded into the IV
+// actually affect the keystream.
+func TestEncryptDecryptAESRoundTrip(t *testing.T) {
+	e := &usmEngine{privKey: []byte("0123456789abcdef"), boots: 5, time: 42}
+	plain := []byte("a scopedPDU with no padding requirement under CFB")
+
+	ciphertext, salt, err := encryptAES(e, plain)
+	if err != nil {
+		t.Fatalf("encryptAES: %v", err)
+	}
+	if len(ciphertext) != len(plain) {
+		t.Fatalf("ciphertext length %d, want %d (CFB is a stream cipher)", len(ciphertext), len(plain))
+	}
+
+	got, err := decryptAES(e, ciphertext, salt)
+	if err != nil {
+		t.Fatalf("decryptAES: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("decryptAES = %q, want %q", got, plain)
+	}
+
+	wrongBoots := &usmEngine{privKey: e.privKey, boots: e.boots + 1, time: e.time}
+	if got, err := decryptAES(wrongBoots, ciphertext, salt); err == nil && bytes.Equal(got, plain) {
+		t.Error("decryptAES recovered the plaintext despite mismatched engine boots, IV should differ")
+	}
+}
+
+// fakeV3Agent simulates a single remote SNMPv3 USM agent over a real UDP
+// socket, for exactly the two request/response exchanges a V3Transport
+// makes on its first call: engine discovery, then one real request. It
+// verifies the client's authentication code (when AuthProtocol is set)
+// and decrypts the request (when PrivProtocol is set) the same way a real
+// agent would, using keys it localizes itself from the shared
+// SecurityParameters, before replying with respBindings under the
+// request's own ID, symmetrically encrypted if privacy is in effect.
+type fakeV3Agent struct {
+	t        *testing.T
+	conn     *net.UDPConn
+	sec      SecurityParameters
+	engineID []byte
+	boots    int32
+	time     int32
+}
+
+func newFakeV3Agent(t *testing.T, sec SecurityParameters) *fakeV3Agent {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &fakeV3Agent{
+		t:        t,
+		conn:     conn,
+		sec:      sec,
+		engineID: []byte{0x80, 0x00, 0x1f, 0x88, 0x80, 0x01, 0x02, 0x03, 0x04},
+		boots:    3,
+		time:     77,
+	}
+}
+
+func (a *fakeV3Agent) addr() string { return a.conn.LocalAddr().String() }
+
+// serveDiscoveryAndOne answers the engine-discovery Get, then answers
+// exactly one further request with respBindings, closing done when it is
+// finished (successfully or not — failures are reported via a.t).
+func (a *fakeV3Agent) serveDiscoveryAndOne(respBindings []Binding, done chan<- struct{}) {
+	go func() {
+		defer close(done)
+
+		authKey := localizeKey(a.sec.AuthPassword, a.engineID, authHash(a.sec.AuthProtocol))
+		privKey := localizeKey(a.sec.PrivPassword, a.engineID, authHash(a.sec.AuthProtocol))
+		engine := &usmEngine{id: a.engineID, boots: a.boots, time: a.time, authKey: authKey, privKey: privKey}
+
+		buf := make([]byte, 10000)
+
+		// Engine discovery request: reply with our engine ID/boots/time,
+		// ignoring the request's own (empty) security parameters.
+		if _, src, err := a.conn.ReadFromUDP(buf); err != nil {
+			a.t.Errorf("agent: read discovery request: %v", err)
+			return
+		} else if err := a.replyWithUSM(src, nil, struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		}{}); err != nil {
+			a.t.Errorf("agent: reply to discovery request: %v", err)
+			return
+		}
+
+		// The real request.
+		n, src, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			a.t.Errorf("agent: read request: %v", err)
+			return
+		}
+		var msg v3Message
+		if _, err := asn1.Unmarshal(buf[:n], &msg); err != nil {
+			a.t.Errorf("agent: unmarshal request: %v", err)
+			return
+		}
+		var usm usmSecurityParameters
+		if _, err := asn1.Unmarshal(msg.SecParams, &usm); err != nil {
+			a.t.Errorf("agent: unmarshal request security params: %v", err)
+			return
+		}
+
+		if a.sec.AuthProtocol != NoAuth {
+			offset, err := authParamsOffset(msg, usm)
+			if err != nil {
+				a.t.Errorf("agent: authParamsOffset: %v", err)
+				return
+			}
+			zeroed := append([]byte(nil), buf[:n]...)
+			copy(zeroed[offset:offset+12], make([]byte, 12))
+			mac := hmac.New(authHash(a.sec.AuthProtocol), authKey)
+			mac.Write(zeroed)
+			want := mac.Sum(nil)[:12]
+			if got := usm.AuthenticationParameters; !hmac.Equal(got, want) {
+				a.t.Errorf("agent: request authentication code = %x, want %x", got, want)
+				return
+			}
+		}
+
+		scoped := msg.Data.FullBytes
+		if a.sec.PrivProtocol != NoPriv {
+			var enc []byte
+			if _, err := asn1.Unmarshal(msg.Data.FullBytes, &enc); err != nil {
+				a.t.Errorf("agent: unmarshal encrypted scopedPDU: %v", err)
+				return
+			}
+			scoped, err = decryptScopedPDU(engine, a.sec.PrivProtocol, enc, usm.PrivacyParameters)
+			if err != nil {
+				a.t.Errorf("agent: decryptScopedPDU: %v", err)
+				return
+			}
+		}
+		var sp scopedPDU
+		if _, err := asn1.Unmarshal(scoped, &sp); err != nil {
+			a.t.Errorf("agent: unmarshal scopedPDU: %v", err)
+			return
+		}
+		var reqPDU struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		}
+		if _, err := asn1.UnmarshalWithParams(sp.Data.FullBytes, &reqPDU, "tag:0"); err != nil {
+			a.t.Errorf("agent: unmarshal inner PDU: %v", err)
+			return
+		}
+
+		respPDU := struct {
+			RequestID   int32
+			ErrorStatus int
+			ErrorIndex  int
+			Bindings    []Binding
+		}{RequestID: reqPDU.RequestID, Bindings: respBindings}
+		if err := a.replyWithUSM(src, engine, respPDU); err != nil {
+			a.t.Errorf("agent: reply to request: %v", err)
+		}
+	}()
+}
+
+// replyWithUSM marshals pdu as a tag-2 Response-PDU inside a ScopedPDU,
+// encrypts it if engine is non-nil and a.sec.PrivProtocol is set, and
+// sends it to src carrying a's engine ID/boots/time.
+func (a *fakeV3Agent) replyWithUSM(src *net.UDPAddr, engine *usmEngine, pdu struct {
+	RequestID   int32
+	ErrorStatus int
+	ErrorIndex  int
+	Bindings    []Binding
+}) error {
+	pduBytes, err := asn1.MarshalWithParams(pdu, "tag:2")
+	if err != nil {
+		return err
+	}
+	sp := scopedPDU{Data: asn1.RawValue{FullBytes: pduBytes}}
+	plain, err := asn1.Marshal(sp)
+	if err != nil {
+		return err
+	}
+
+	data := plain
+	var privParams []byte
+	if engine != nil && a.sec.PrivProtocol != NoPriv {
+		data, privParams, err = encryptScopedPDU(engine, a.sec.PrivProtocol, plain)
+		if err != nil {
+			return err
+		}
+	}
+
+	usm := usmSecurityParameters{
+		AuthoritativeEngineID:    a.engineID,
+		AuthoritativeEngineBoots: a.boots,
+		AuthoritativeEngineTime:  a.time,
+		PrivacyParameters:        privParams,
+	}
+	secParams, err := asn1.Marshal(usm)
+	if err != nil {
+		return err
+	}
+
+	msg := v3Message{Version: 3, MsgID: 1, MaxSize: 65507, SecModel: usmSecurityModel, SecParams: secParams}
+	if engine != nil && a.sec.PrivProtocol != NoPriv {
+		msg.Data = asn1.RawValue{FullBytes: mustOctetString(data)}
+	} else {
+		msg.Data = asn1.RawValue{FullBytes: data}
+	}
+	buf, err := asn1.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = a.conn.WriteToUDP(buf, src)
+	return err
+}
+
+// dialFakeV3Agent dials a V3Transport's Conn at addr.
+func dialFakeV3Agent(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		t.Fatalf("ResolveUDPAddr: %v", err)
+	}
+	conn, err := net.DialUDP("udp", nil, udpAddr)
+	if err != nil {
+		t.Fatalf("DialUDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitForAgent fails the test if the agent goroutine doesn't finish
+// (successfully or not) within a reasonable time.
+func waitForAgent(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("agent goroutine did not finish in time")
+	}
+}
+
+// TestV3TransportRoundTripAuthenticated drives V3Transport.RoundTrip
+// end-to-end against a fake UDP agent: engine discovery followed by an
+// authenticated (HMAC-MD5-96) Get, verifying the agent accepts the
+// client's authentication code and the client decodes the agent's reply.
+func TestV3TransportRoundTripAuthenticated(t *testing.T) {
+	sec := SecurityParameters{Username: "tester", AuthProtocol: AuthMD5, AuthPassword: "maplesyrup"}
+	agent := newFakeV3Agent(t, sec)
+
+	upTime, err := asn1.MarshalWithParams(int64(12345), "application,tag:3")
+	if err != nil {
+		t.Fatalf("marshal sysUpTime: %v", err)
+	}
+	wantBindings := []Binding{{Name: oidSysUpTime, Value: asn1.RawValue{FullBytes: upTime}}}
+
+	done := make(chan struct{})
+	agent.serveDiscoveryAndOne(wantBindings, done)
+
+	tr := &V3Transport{Conn: dialFakeV3Agent(t, agent.addr()), Security: sec, Timeout: time.Second}
+	resp, err := tr.RoundTrip(&Request{ID: 99, Type: "Get", Bindings: []Binding{{Name: oidSysUpTime}}})
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if len(resp.Bindings) != 1 || !oidEqual(resp.Bindings[0].Name, oidSysUpTime) {
+		t.Fatalf("resp.Bindings = %+v, want one binding for sysUpTime", resp.Bindings)
+	}
+	var got int64
+	if err := resp.Bindings[0].unmarshal(nil, &got); err != nil {
+		t.Fatalf("unmarshal sysUpTime: %v", err)
+	}
+	if got != 12345 {
+		t.Errorf("sysUpTime = %d, want 12345", got)
+	}
+
+	waitForAgent(t, done)
+}
+
+// TestV3TransportRoundTripAuthPriv drives V3Transport.RoundTrip
+// end-to-end with both authentication (HMAC-SHA-96) and privacy
+// (AES-128-CFB) enabled, verifying the agent can decrypt the client's
+// request and the client can decrypt the agent's encrypted reply.
+func TestV3TransportRoundTripAuthPriv(t *testing.T) {
+	sec := SecurityParameters{
+		Username:     "tester",
+		AuthProtocol: AuthSHA,
+		AuthPassword: "maplesyrup",
+		PrivProtocol: PrivAES128,
+		PrivPassword: "maplesyrup2",
+	}
+	agent := newFakeV3Agent(t, sec)
+
+	upTime, err := asn1.MarshalWithParams(int64(67890), "application,tag:3")
+	if err != nil {
+		t.Fatalf("marshal sysUpTime: %v", err)
+	}
+	wantBindings := []Binding{{Name: oidSysUpTime, Value: asn1.RawValue{FullBytes: upTime}}}
+
+	done := make(chan struct{})
+	agent.serveDiscoveryAndOne(wantBindings, done)
+
+	tr := &V3Transport{Conn: dialFakeV3Agent(t, agent.addr()), Security: sec, Timeout: time.Second}
+	resp, err := tr.RoundTrip(&Request{ID: 100, Type: "Get", Bindings: []Binding{{Name: oidSysUpTime}}})
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if len(resp.Bindings) != 1 || !oidEqual(resp.Bindings[0].Name, oidSysUpTime) {
+		t.Fatalf("resp.Bindings = %+v, want one binding for sysUpTime", resp.Bindings)
+	}
+	var got int64
+	if err := resp.Bindings[0].unmarshal(nil, &got); err != nil {
+		t.Fatalf("unmarshal sysUpTime: %v", err)
+	}
+	if got != 67890 {
+		t.Errorf("sysUpTime = %d, want 67890", got)
+	}
+
+	waitForAgent(t, done)
+}
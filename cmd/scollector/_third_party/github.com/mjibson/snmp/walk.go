@@ -0,0 +1,122 @@
+package snmp
+
+import "encoding/asn1"
+
+// isEndOfMibView reports whether v is the SNMPv2 endOfMibView exception
+// value, signaling that a walk has reached the end of the agent's MIB.
+func isEndOfMibView(v asn1.RawValue) bool {
+	return v.Class == endOfMibView.Class && v.Tag == endOfMibView.Tag
+}
+
+// Walk retrieves every binding in the subtree rooted at root by issuing
+// repeated GetNext requests over rt, stopping once a returned name falls
+// outside the subtree or the agent reports endOfMibView. It also stops, and
+// returns what it has collected so far, at the first binding that is not
+// lexicographically after the last one accepted, since some agents
+// incorrectly echo back a non-advancing OID; without that check a buggy
+// agent would make Walk loop forever. community
+// is currently unused: the community string is carried by rt's own
+// Transport, not per walk; it is accepted here so a future per-request
+// override can be threaded through without an API break.
+func Walk(rt RoundTripper, community string, root asn1.ObjectIdentifier) ([]Binding, error) {
+	var out []Binding
+	last := Binding{Name: root}
+	for {
+		req := &Request{
+			ID:       <-nextID,
+			Type:     "GetNext",
+			Bindings: []Binding{{Name: last.Name}},
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			return out, err
+		}
+		if len(resp.Bindings) == 0 {
+			return out, nil
+		}
+		b := resp.Bindings[0]
+		if !hasPrefix(b.Name, root) || isEndOfMibView(b.Value) {
+			return out, nil
+		}
+		if !last.less(b) {
+			return out, nil
+		}
+		out = append(out, b)
+		last = b
+	}
+}
+
+// BulkWalk retrieves every binding in the subtree rooted at root by issuing
+// repeated GetBulk requests over rt, requesting maxRepetitions repetitions
+// per call, and invokes fn for each binding as it arrives. It stops once a
+// returned name falls outside the subtree or the agent reports
+// endOfMibView, and discards any binding that is lexicographically at or
+// before the last one accepted, since some agents incorrectly return
+// repetitions out of order.
+func BulkWalk(rt RoundTripper, root asn1.ObjectIdentifier, maxRepetitions int, fn func(Binding) error) error {
+	last := Binding{Name: root}
+	for {
+		req := &Request{
+			ID:             <-nextID,
+			Type:           "GetBulk",
+			Bindings:       []Binding{{Name: last.Name}},
+			MaxRepetitions: maxRepetitions,
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+		if len(resp.Bindings) == 0 {
+			return nil
+		}
+		advanced := false
+		for _, b := range resp.Bindings {
+			if !hasPrefix(b.Name, root) || isEndOfMibView(b.Value) {
+				return nil
+			}
+			if !last.less(b) {
+				continue
+			}
+			if err := fn(b); err != nil {
+				return err
+			}
+			last = b
+			advanced = true
+		}
+		if !advanced {
+			return nil
+		}
+	}
+}
+
+// TableRow is one row of a table retrieved by WalkTable, keyed by column
+// OID in dotted-decimal form.
+type TableRow map[string]Binding
+
+// WalkTable walks each of columns and aligns the results into rows keyed
+// by index OID suffix — the portion of each binding's name past its
+// column OID — the common shape needed to consume MIB-2 tables such as
+// ifTable. community is currently unused; see Walk.
+func WalkTable(rt RoundTripper, community string, columns []asn1.ObjectIdentifier) (map[string]TableRow, error) {
+	rows := make(map[string]TableRow)
+	for _, col := range columns {
+		bindings, err := Walk(rt, community, col)
+		if err != nil {
+			return nil, err
+		}
+		key := col.String()
+		for _, b := range bindings {
+			if !hasPrefix(b.Name, col) {
+				continue
+			}
+			idx := asn1.ObjectIdentifier(b.Name[len(col):]).String()
+			row, ok := rows[idx]
+			if !ok {
+				row = make(TableRow)
+				rows[idx] = row
+			}
+			row[key] = b
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,171 @@
+package snmp
+
+import (
+	"context"
+	"encoding/asn1"
+	"testing"
+)
+
+// scriptedRoundTripper returns successive Responses from responses,
+// regardless of the Request, for exercising Walk/BulkWalk without a real
+// Transport.
+type scriptedRoundTripper struct {
+	responses []*Response
+	calls     int
+}
+
+func (rt *scriptedRoundTripper) RoundTrip(req *Request) (*Response, error) {
+	return rt.RoundTripContext(context.Background(), req)
+}
+
+func (rt *scriptedRoundTripper) RoundTripContext(ctx context.Context, req *Request) (*Response, error) {
+	if rt.calls >= len(rt.responses) {
+		return &Response{ID: req.ID}, nil
+	}
+	resp := rt.responses[rt.calls]
+	rt.calls++
+	return resp, nil
+}
+
+func oid(parts ...int) asn1.ObjectIdentifier {
+	return asn1.ObjectIdentifier(parts)
+}
+
+// TestBulkWalkOutOfOrder verifies BulkWalk discards bindings a buggy agent
+// returns out of order (lexicographically at or before the last one
+// accepted) instead of passing them to fn or regressing the walk cursor.
+func TestBulkWalkOutOfOrder(t *testing.T) {
+	root := oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 10)
+	rt := &scriptedRoundTripper{
+		responses: []*Response{
+			{Bindings: []Binding{
+				{Name: append(root, 1)},
+				{Name: append(root, 3)},
+				{Name: append(root, 2)}, // out of order: before the last accepted binding
+				{Name: append(root, 5)},
+			}},
+			{Bindings: []Binding{
+				{Name: oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 11)}, // outside the subtree
+			}},
+		},
+	}
+
+	var got []asn1.ObjectIdentifier
+	err := BulkWalk(rt, root, 10, func(b Binding) error {
+		got = append(got, b.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("BulkWalk: %v", err)
+	}
+	want := []string{"1.3.6.1.2.1.2.2.1.10.1", "1.3.6.1.2.1.2.2.1.10.3", "1.3.6.1.2.1.2.2.1.10.5"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d bindings, want %d: %v", len(got), len(want), got)
+	}
+	for i, g := range got {
+		if g.String() != want[i] {
+			t.Errorf("binding %d = %s, want %s", i, g.String(), want[i])
+		}
+	}
+}
+
+// TestBulkWalkEndOfMibView verifies BulkWalk stops as soon as the agent
+// reports endOfMibView, without invoking fn for that binding.
+func TestBulkWalkEndOfMibView(t *testing.T) {
+	root := oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 10)
+	rt := &scriptedRoundTripper{
+		responses: []*Response{
+			{Bindings: []Binding{
+				{Name: append(root, 1)},
+				{Name: append(root, 2), Value: endOfMibView},
+			}},
+		},
+	}
+
+	var calls int
+	err := BulkWalk(rt, root, 10, func(b Binding) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("BulkWalk: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
+
+// TestWalkStopsOutsideSubtree verifies Walk stops once GetNext returns a
+// name outside root's subtree.
+func TestWalkStopsOutsideSubtree(t *testing.T) {
+	root := oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 10)
+	rt := &scriptedRoundTripper{
+		responses: []*Response{
+			{Bindings: []Binding{{Name: append(root, 1)}}},
+			{Bindings: []Binding{{Name: append(root, 2)}}},
+			{Bindings: []Binding{{Name: oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 11)}}},
+		},
+	}
+
+	got, err := Walk(rt, "", root)
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d bindings, want 2: %v", len(got), got)
+	}
+}
+
+// TestWalkNonAdvancing verifies Walk stops rather than looping forever when
+// a buggy agent echoes back a non-advancing OID in response to GetNext.
+func TestWalkNonAdvancing(t *testing.T) {
+	root := oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 10)
+	rt := &scriptedRoundTripper{
+		responses: []*Response{
+			{Bindings: []Binding{{Name: append(root, 1)}}},
+			{Bindings: []Binding{{Name: append(root, 1)}}}, // non-advancing: same OID again
+			{Bindings: []Binding{{Name: append(root, 2)}}},
+		},
+	}
+
+	got, err := Walk(rt, "", root)
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d bindings, want 1: %v", len(got), got)
+	}
+}
+
+// TestWalkTableAlignsColumns verifies WalkTable keys rows by the index OID
+// suffix shared across columns.
+func TestWalkTableAlignsColumns(t *testing.T) {
+	ifDescr := oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 2)
+	ifSpeed := oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 5)
+
+	rt := &scriptedRoundTripper{
+		responses: []*Response{
+			// Walk(ifDescr)
+			{Bindings: []Binding{{Name: append(append(asn1.ObjectIdentifier{}, ifDescr...), 1)}}},
+			{Bindings: []Binding{{Name: oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 3)}}}, // ends ifDescr walk
+			// Walk(ifSpeed)
+			{Bindings: []Binding{{Name: append(append(asn1.ObjectIdentifier{}, ifSpeed...), 1)}}},
+			{Bindings: []Binding{{Name: oid(1, 3, 6, 1, 2, 1, 2, 2, 1, 6)}}}, // ends ifSpeed walk
+		},
+	}
+
+	rows, err := WalkTable(rt, "", []asn1.ObjectIdentifier{ifDescr, ifSpeed})
+	if err != nil {
+		t.Fatalf("WalkTable: %v", err)
+	}
+	row, ok := rows["1"]
+	if !ok {
+		t.Fatalf("rows = %v, want a row keyed %q", rows, "1")
+	}
+	if _, ok := row[ifDescr.String()]; !ok {
+		t.Errorf("row %v missing ifDescr column", row)
+	}
+	if _, ok := row[ifSpeed.String()]; !ok {
+		t.Errorf("row %v missing ifSpeed column", row)
+	}
+}